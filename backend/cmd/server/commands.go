@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/database"
+	"image-uploader-backend/internal/repository"
+	"image-uploader-backend/internal/service"
+	"image-uploader-backend/migrations"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const commandsUsage = `Usage: server [command]
+
+Without a command the HTTP server is started.
+
+Commands:
+  migrate-storage   copy all image files to another storage (see -h)
+`
+
+// runCommand выполняет служебную команду вместо запуска сервера и
+// возвращает код завершения
+func runCommand(cfg *config.Config, args []string) int {
+	switch args[0] {
+	case "migrate-storage":
+		return migrateStorage(cfg, args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(os.Stdout, commandsUsage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", args[0], commandsUsage)
+		return 2
+	}
+}
+
+// migrateStorage переносит файлы из текущего хранилища (STORAGE_BACKEND,
+// UPLOAD_DIR, S3_*) в хранилище, заданное теми же переменными с префиксом
+// TARGET_. Сервер на время переноса лучше остановить; если он работал,
+// повторный запуск докопирует загруженное за это время.
+func migrateStorage(cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "check source objects and report what would be copied without writing")
+	batchSize := flags.Int("batch-size", 100, "objects per batch; progress is saved after each batch")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), `Usage: server migrate-storage [flags]
+
+Copies every image file referenced by the database from the current storage
+(STORAGE_BACKEND, UPLOAD_DIR, S3_*) to the target storage configured by the
+same variables with the TARGET_ prefix (TARGET_STORAGE_BACKEND is required,
+TARGET_UPLOAD_DIR, TARGET_S3_BUCKET, ...). Each copy is verified by size and
+SHA-256. Interrupted runs resume where they stopped. After a successful run
+switch the storage settings to the target and restart the server.
+
+Flags:
+`)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	// Без явной цели значения по умолчанию указали бы на ./uploads
+	if os.Getenv("TARGET_STORAGE_BACKEND") == "" {
+		log.Printf("TARGET_STORAGE_BACKEND is required (local or s3)")
+		return 2
+	}
+	target := config.LoadStorage("TARGET_")
+	if target.Location() == cfg.Storage.Location() {
+		log.Printf("Target storage %s is the same as the current one; set TARGET_* variables", target.Location())
+		return 2
+	}
+
+	db, err := database.Open(cfg.DBPath)
+	if err != nil {
+		log.Printf("Database error: %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	if err := database.Migrate(db, migrations.FS); err != nil {
+		log.Printf("Migration error: %v", err)
+		return 1
+	}
+
+	source, err := openStorage(cfg.Storage, cfg.BaseURL)
+	if err != nil {
+		log.Printf("Source storage error: %v", err)
+		return 1
+	}
+	dest, err := openStorage(target, cfg.BaseURL)
+	if err != nil {
+		log.Printf("Target storage error: %v", err)
+		return 1
+	}
+
+	// Записи, созданные до появления ключей хранилища, должны ссылаться на ключи
+	imageService := service.NewImageService(repository.NewImageRepository(db), repository.NewAlbumRepository(db),
+		service.NewQuotaService(repository.NewQuotaRepository(db), cfg), source, cfg)
+	if _, err := imageService.BackfillStorageKeys(); err != nil {
+		log.Printf("Failed to convert file paths to storage keys: %v", err)
+		return 1
+	}
+
+	// Ctrl+C останавливает перенос после текущего объекта с сохранением прогресса
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Migrating storage %s -> %s (dry run: %t)", cfg.Storage.Location(), target.Location(), *dryRun)
+	migrator := service.NewStorageMigrator(repository.NewStorageMigrationRepository(db), source, dest, target.Location())
+	report, err := migrator.Run(ctx, service.StorageMigrationOptions{DryRun: *dryRun, BatchSize: *batchSize})
+
+	action := "copied"
+	if *dryRun {
+		action = "to copy"
+	}
+	log.Printf("Objects: %d total, %d %s (%d bytes), %d already migrated, %d failed",
+		report.Total, report.Copied, action, report.Bytes, report.Skipped, report.Failed)
+
+	if err != nil {
+		log.Printf("Storage migration stopped: %v", err)
+		return 1
+	}
+	if report.Failed > 0 {
+		log.Printf("Some objects failed; fix the errors above and run the command again")
+		return 1
+	}
+	if !*dryRun {
+		log.Printf("All objects are in the target storage; switch STORAGE_BACKEND/UPLOAD_DIR/S3_* to it and restart the server")
+	}
+	return 0
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/database"
+	"image-uploader-backend/internal/handlers"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"image-uploader-backend/internal/service"
+	"image-uploader-backend/migrations"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentryecho "github.com/getsentry/sentry-go/echo"
+	"github.com/labstack/echo/v4"
+	echomw "github.com/labstack/echo/v4/middleware"
+)
+
+func main() {
+	cfg := config.Load()
+
+	// Служебные команды (например, migrate-storage) вместо запуска сервера
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(cfg, os.Args[1:]))
+	}
+
+	// Sentry подключаем только если задан DSN
+	if cfg.SentryDSN != "" {
+		if err := sentry.Init(sentry.ClientOptions{Dsn: cfg.SentryDSN}); err != nil {
+			log.Printf("Sentry initialization failed: %v", err)
+		}
+		defer sentry.Flush(2 * time.Second)
+	}
+
+	db, err := database.Open(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Database error: %v", err)
+	}
+	defer db.Close()
+
+	// Применяем миграции схемы
+	if err := database.Migrate(db, migrations.FS); err != nil {
+		log.Fatalf("Migration error: %v", err)
+	}
+
+	// Репозитории
+	userRepo := repository.NewUserRepository(db)
+	imageRepo := repository.NewImageRepository(db)
+	tokenRepo := repository.NewTokenRepository(db)
+	inviteRepo := repository.NewInviteRepository(db)
+	tusRepo := repository.NewTusRepository(db)
+	shareRepo := repository.NewShareRepository(db)
+	albumRepo := repository.NewAlbumRepository(db)
+	quotaRepo := repository.NewQuotaRepository(db)
+
+	// Хранилище сессий: по умолчанию в SQLite, чтобы логины переживали рестарт
+	var sessionStore repository.SessionStore
+	switch cfg.SessionStore {
+	case "memory":
+		sessionStore = repository.NewMemorySessionStore()
+	case "sqlite":
+		sessionStore = repository.NewSQLiteSessionStore(db)
+	default:
+		log.Fatalf("Unknown SESSION_STORE %q (expected sqlite or memory)", cfg.SessionStore)
+	}
+
+	switch cfg.RegistrationMode {
+	case models.RegistrationOpen, models.RegistrationInvite, models.RegistrationClosed:
+	default:
+		log.Fatalf("Unknown REGISTRATION_MODE %q (expected open, invite or closed)", cfg.RegistrationMode)
+	}
+
+	switch cfg.ExifStrip {
+	case models.StripNone, models.StripGPS, models.StripAll:
+	default:
+		log.Fatalf("Unknown EXIF_STRIP %q (expected none, gps or all)", cfg.ExifStrip)
+	}
+
+	switch cfg.ImageContentDisposition {
+	case "", "inline", "attachment":
+	default:
+		log.Fatalf("Unknown IMAGE_CONTENT_DISPOSITION %q (expected inline, attachment or empty)", cfg.ImageContentDisposition)
+	}
+
+	if !models.ValidVisibility(cfg.DefaultVisibility) {
+		log.Fatalf("Unknown DEFAULT_VISIBILITY %q (expected public, unlisted or private)", cfg.DefaultVisibility)
+	}
+
+	// Слабый секрет позволил бы подобрать подпись ссылок
+	for _, key := range cfg.URLSigningKeys {
+		if len(key.Secret) < 32 {
+			log.Fatalf("URL signing key %q is too short (need at least 32 characters)", key.ID)
+		}
+	}
+
+	// Хранилище файлов изображений
+	store, err := openStorage(cfg.Storage, cfg.BaseURL)
+	if err != nil {
+		log.Fatalf("Storage error: %v", err)
+	}
+
+	// Сервисы
+	authService := service.NewAuthService(userRepo, tokenRepo, inviteRepo, sessionStore, cfg.RegistrationMode)
+	quotaService := service.NewQuotaService(quotaRepo, cfg)
+	imageService := service.NewImageService(imageRepo, albumRepo, quotaService, store, cfg)
+	transformService := service.NewTransformService(store, cfg)
+	tusService := service.NewTusService(tusRepo, imageService, quotaService, cfg)
+	shareService := service.NewShareService(shareRepo, imageService, cfg)
+	albumService := service.NewAlbumService(albumRepo, imageService, cfg)
+	userService := service.NewUserService(userRepo, sessionStore, imageService, tusService)
+	moderationService := service.NewModerationService(imageService, userRepo)
+
+	// Пути на диске из строк, созданных до появления ключей хранилища
+	if n, err := imageService.BackfillStorageKeys(); err != nil {
+		log.Fatalf("Failed to convert file paths to storage keys: %v", err)
+	} else if n > 0 {
+		log.Printf("Converted %d file paths to storage keys", n)
+	}
+
+	// Дочищаем файлы, которые не удалось удалить до перезапуска
+	if err := imageService.PurgePendingDeletions(); err != nil {
+		log.Printf("Failed to purge pending file deletions: %v", err)
+	}
+	if err := tusService.PurgeExpired(); err != nil {
+		log.Printf("Failed to purge expired uploads: %v", err)
+	}
+
+	// Первый администратор создается из конфигурации
+	if cfg.AdminUsername != "" && cfg.AdminPassword != "" {
+		created, err := authService.EnsureAdmin(cfg.AdminUsername, cfg.AdminPassword)
+		if err != nil {
+			log.Fatalf("Failed to create admin user: %v", err)
+		}
+		if created {
+			log.Printf("Created admin user %s", cfg.AdminUsername)
+		}
+	}
+
+	// Обработчики
+	authHandler := handlers.NewAuthHandler(authService)
+	uploadHandler := handlers.NewUploadHandler(imageService, handlers.BatchLimits{
+		MaxFiles:    cfg.BatchMaxFiles,
+		MaxBytes:    cfg.BatchMaxBytes,
+		MaxFileSize: cfg.MaxFileSize,
+	})
+	imageHandler := handlers.NewImageHandler(imageService)
+	adminHandler := handlers.NewAdminHandler(imageService, shareService, userService, moderationService, userRepo)
+	tokenHandler := handlers.NewTokenHandler(authService)
+	inviteHandler := handlers.NewInviteHandler(authService)
+	transformHandler := handlers.NewTransformHandler(transformService, imageService, cfg.ImageCacheControl)
+	serveHandler := handlers.NewServeHandler(imageService, handlers.ServeOptions{
+		CacheControl:       cfg.ImageCacheControl,
+		ContentDisposition: cfg.ImageContentDisposition,
+	})
+	tusHandler := handlers.NewTusHandler(tusService, cfg.MaxFileSize)
+	shareHandler := handlers.NewShareHandler(shareService, serveHandler)
+	albumHandler := handlers.NewAlbumHandler(albumService)
+	tagHandler := handlers.NewTagHandler(imageService)
+	quotaHandler := handlers.NewQuotaHandler(quotaService)
+
+	e := echo.New()
+	e.HideBanner = true
+
+	e.Use(echomw.Logger())
+	e.Use(echomw.Recover())
+	if cfg.SentryDSN != "" {
+		e.Use(sentryecho.New(sentryecho.Options{Repanic: true}))
+	}
+	e.Use(echomw.CORSWithConfig(echomw.CORSConfig{
+		// OPTIONS без Access-Control-Request-Method — не preflight, а запрос
+		// к серверу (например, обнаружение возможностей tus)
+		Skipper: func(c echo.Context) bool {
+			req := c.Request()
+			return req.Method == http.MethodOptions && req.Header.Get(echo.HeaderAccessControlRequestMethod) == ""
+		},
+		AllowOrigins:     cfg.CORSOrigins,
+		AllowMethods:     []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+		AllowCredentials: true,
+		ExposeHeaders:    handlers.TusExposedHeaders,
+	}))
+
+	// Health check
+	health := func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"status": "OK"})
+	}
+	e.GET("/health", health)
+	e.HEAD("/health", health)
+
+	// Загруженные изображения из любого хранилища; отдаются только файлы,
+	// известные базе и доступные с учетом видимости изображения.
+	// Запросы с ?w=&h=&fit= перехватывает преобразование.
+	viewer := middleware.OptionalAuth(authService, models.TokenScopeRead)
+	e.GET("/images/*", serveHandler.Serve, viewer, transformHandler.Middleware)
+	e.HEAD("/images/*", serveHandler.Serve, viewer, transformHandler.Middleware)
+
+	// Открытие ссылок на изображения; файлы доступны по токену просмотра
+	e.GET("/s/:slug", shareHandler.Open)
+	e.POST("/s/:slug", shareHandler.Open)
+	e.GET("/s/:slug/images/:id", shareHandler.ServeFile)
+	e.HEAD("/s/:slug/images/:id", shareHandler.ServeFile)
+	e.GET("/s/:slug/images/:id/:variant", shareHandler.ServeFile)
+	e.HEAD("/s/:slug/images/:id/:variant", shareHandler.ServeFile)
+
+	api := e.Group("/api")
+
+	// Аутентификация
+	auth := api.Group("/auth")
+	auth.GET("/registration", authHandler.GetRegistration)
+	auth.POST("/register", authHandler.Register)
+	auth.POST("/login", authHandler.Login)
+	auth.POST("/logout", authHandler.Logout, middleware.RequireAuth(authService))
+	auth.GET("/me", authHandler.GetMe, middleware.RequireAuth(authService, models.TokenScopeRead))
+	auth.POST("/password", authHandler.ChangePassword)
+
+	// Персональные API токены управляются только из сессии
+	tokens := api.Group("/tokens", middleware.RequireAuth(authService))
+	tokens.POST("", tokenHandler.CreateToken)
+	tokens.GET("", tokenHandler.ListTokens)
+	tokens.DELETE("/:id", tokenHandler.RevokeToken)
+
+	// Загрузка изображений
+	// Запас на multipart-обвязку сверх максимального размера файла
+	uploadLimit := echomw.BodyLimit(formatBodyLimit(cfg.MaxFileSize + 1024*1024))
+	api.POST("/upload", uploadHandler.UploadImage, uploadLimit, middleware.RequireUser(authService, models.TokenScopeUpload))
+
+	// Пакетная загрузка: тело ограничено суммарным лимитом с тем же запасом
+	batchLimit := echomw.BodyLimit(formatBodyLimit(cfg.BatchMaxBytes + 1024*1024))
+	api.POST("/upload/batch", uploadHandler.UploadBatch, batchLimit, middleware.RequireUser(authService, models.TokenScopeUpload))
+
+	// Возобновляемая загрузка по протоколу tus
+	tusAuth := middleware.RequireUser(authService, models.TokenScopeUpload)
+	tus := api.Group("/uploads/tus", tusHandler.Middleware)
+	tus.OPTIONS("", tusHandler.Options)
+	tus.POST("", tusHandler.Create, tusAuth)
+	tus.HEAD("/:id", tusHandler.Head, tusAuth)
+	tus.PATCH("/:id", tusHandler.Patch, tusAuth)
+	tus.DELETE("/:id", tusHandler.Delete, tusAuth)
+
+	// Изображения пользователя
+	images := api.Group("/images")
+	images.GET("", imageHandler.ListImages, middleware.RequireAuth(authService, models.TokenScopeRead))
+	images.GET("/search", imageHandler.Search, middleware.RequireAuth(authService, models.TokenScopeRead))
+	images.GET("/:id", imageHandler.GetImage, middleware.RequireAuth(authService, models.TokenScopeRead))
+	images.POST("/:id/signed-url", imageHandler.SignURL, middleware.RequireAuth(authService, models.TokenScopeRead))
+	images.PATCH("/:id", imageHandler.UpdateImage, middleware.RequireAuth(authService))
+	images.DELETE("/:id", imageHandler.DeleteImage, middleware.RequireAuth(authService))
+
+	// Ссылки на изображения пользователя
+	shares := api.Group("/shares")
+	shares.POST("", shareHandler.CreateShareLink, middleware.RequireAuth(authService))
+	shares.GET("", shareHandler.ListShareLinks, middleware.RequireAuth(authService, models.TokenScopeRead))
+	shares.DELETE("/:id", shareHandler.RevokeShareLink, middleware.RequireAuth(authService))
+
+	// Теги своих изображений
+	tags := api.Group("/tags", middleware.RequireAuth(authService, models.TokenScopeRead))
+	tags.GET("", tagHandler.ListTags)
+	tags.GET("/autocomplete", tagHandler.Autocomplete)
+
+	// Использование хранилища и квота текущего пользователя
+	api.GET("/quota", quotaHandler.GetQuota, middleware.RequireAuth(authService, models.TokenScopeRead))
+
+	// Альбомы; просмотр доступен и без входа с учетом видимости альбома
+	albums := api.Group("/albums")
+	albums.POST("", albumHandler.CreateAlbum, middleware.RequireAuth(authService))
+	albums.GET("", albumHandler.ListAlbums, middleware.RequireAuth(authService, models.TokenScopeRead))
+	albums.GET("/:id", albumHandler.GetAlbum, viewer)
+	albums.PATCH("/:id", albumHandler.UpdateAlbum, middleware.RequireAuth(authService))
+	albums.DELETE("/:id", albumHandler.DeleteAlbum, middleware.RequireAuth(authService))
+	albums.POST("/:id/images", albumHandler.AddImages, middleware.RequireAuth(authService))
+	albums.PUT("/:id/images", albumHandler.ReorderImages, middleware.RequireAuth(authService))
+	albums.DELETE("/:id/images/:image_id", albumHandler.RemoveImage, middleware.RequireAuth(authService))
+
+	// Административные endpoints
+	admin := api.Group("/admin", middleware.RequireAdmin(authService))
+	admin.GET("/users", adminHandler.GetUsers)
+	admin.POST("/users", adminHandler.CreateUser)
+	admin.PATCH("/users/:id", adminHandler.UpdateUser)
+	admin.DELETE("/users/:id", adminHandler.DeleteUser)
+	admin.POST("/users/:id/password-reset", adminHandler.ResetPassword)
+	admin.GET("/users/:id/images", adminHandler.GetUserImages)
+	admin.GET("/users/:id/quota", quotaHandler.GetUserQuota)
+	admin.PUT("/users/:id/quota", quotaHandler.SetUserQuota)
+	admin.GET("/images", adminHandler.ListImages)
+	admin.POST("/images/bulk", adminHandler.BulkImages)
+	admin.DELETE("/images/:id", adminHandler.DeleteImage)
+	admin.GET("/shares", adminHandler.GetShareLinks)
+	admin.DELETE("/shares/:id", adminHandler.RevokeShareLink)
+	admin.POST("/invites", inviteHandler.CreateInvite)
+	admin.GET("/invites", inviteHandler.ListInvites)
+	admin.DELETE("/invites/:id", inviteHandler.RevokeInvite)
+
+	// Запуск сервера с корректной остановкой по сигналу
+	go func() {
+		if err := e.Start(":" + cfg.Port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+}
+
+// formatBodyLimit переводит размер в байтах в формат BodyLimit ("11M")
+func formatBodyLimit(size int64) string {
+	const mb = 1024 * 1024
+	return strconv.FormatInt((size+mb-1)/mb, 10) + "M"
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/storage"
+)
+
+// openStorage создает хранилище по настройкам. baseURL нужен локальному
+// хранилищу: его файлы раздает сам сервер по /images.
+func openStorage(sc config.StorageConfig, baseURL string) (storage.Storage, error) {
+	switch sc.Backend {
+	case "local":
+		return storage.NewLocal(sc.LocalDir, baseURL+"/images")
+	case "s3":
+		return storage.NewS3(storage.S3Options{
+			Endpoint:   sc.S3Endpoint,
+			Region:     sc.S3Region,
+			Bucket:     sc.S3Bucket,
+			AccessKey:  sc.S3AccessKey,
+			SecretKey:  sc.S3SecretKey,
+			UseSSL:     sc.S3UseSSL,
+			PathStyle:  sc.S3PathStyle,
+			Prefix:     sc.S3Prefix,
+			PublicURL:  sc.S3PublicURL,
+			PresignTTL: sc.S3PresignTTL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND %q (expected local or s3)", sc.Backend)
+	}
+}
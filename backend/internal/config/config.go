@@ -1,6 +1,13 @@
 package config
 
-import "os"
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
 	Port         string
@@ -8,8 +15,102 @@ type Config struct {
 	UploadDir    string
 	BaseURL      string
 	SentryDSN    string
+	SessionStore string
+
+	// Регистрация и первичный администратор
+	RegistrationMode string
+	AdminUsername    string
+	AdminPassword    string
+
+	CORSOrigins  []string
 	MaxFileSize  int64
 	AllowedTypes []string
+
+	// Хранилище файлов изображений
+	Storage StorageConfig
+
+	// Заголовки при отдаче файлов по /images: Cache-Control и
+	// Content-Disposition (пусто, inline или attachment)
+	ImageCacheControl       string
+	ImageContentDisposition string
+
+	// Видимость загрузок, для которых она не указана (public, unlisted, private)
+	DefaultVisibility string
+
+	// Ключи подписи ссылок с ограниченным сроком: первым подписываются новые
+	// ссылки, остальные только проверяются (ротация). Пусто — подпись отключена.
+	URLSigningKeys      []SigningKey
+	SignedURLDefaultTTL time.Duration
+	SignedURLMaxTTL     time.Duration
+
+	// Ограничения пакетной загрузки на один запрос
+	BatchMaxFiles int
+	BatchMaxBytes int64
+
+	// Ограничения на размеры изображения (защита от decompression bomb)
+	MaxImageWidth  int
+	MaxImageHeight int
+	MaxImagePixels int64
+
+	// Уменьшенные копии, создаваемые при загрузке
+	Variants []VariantSpec
+
+	// Обработка EXIF при сохранении: что вырезать (none, gps, all)
+	// и поворачивать ли изображение по тегу Orientation
+	ExifStrip  string
+	AutoRotate bool
+
+	// Преобразование изображений по запросу (?w=&h=&fit=)
+	TransformSizes         []int
+	TransformCacheDir      string
+	TransformCacheMaxBytes int64
+	TransformConcurrency   int
+
+	// Срок жизни незавершенной tus-загрузки с момента последней записи
+	TusUploadTTL time.Duration
+
+	// Квоты пользователей: общие по умолчанию и отдельные для ролей.
+	// Администратор может задать пользователю свои лимиты.
+	DefaultQuota Quota
+	RoleQuotas   map[string]Quota
+}
+
+// Quota — лимиты на суммарный объем и число изображений пользователя;
+// 0 — без ограничения
+type Quota struct {
+	MaxBytes  int64
+	MaxImages int64
+}
+
+// StorageConfig — где лежат файлы изображений: local (папка LocalDir) или s3
+type StorageConfig struct {
+	Backend  string
+	LocalDir string
+
+	S3Endpoint   string
+	S3Region     string
+	S3Bucket     string
+	S3AccessKey  string
+	S3SecretKey  string
+	S3UseSSL     bool
+	S3PathStyle  bool
+	S3Prefix     string
+	S3PublicURL  string
+	S3PresignTTL time.Duration
+}
+
+// SigningKey — секрет HMAC для подписи ссылок; ID указывается в ссылке,
+// чтобы после ротации находить ключ, которым она подписана
+type SigningKey struct {
+	ID     string
+	Secret string
+}
+
+// VariantSpec — именованный размер уменьшенной копии: изображение вписывается
+// в квадрат MaxSize×MaxSize с сохранением пропорций
+type VariantSpec struct {
+	Name    string
+	MaxSize int
 }
 
 func Load() *Config {
@@ -19,14 +120,181 @@ func Load() *Config {
 		UploadDir:    getEnv("UPLOAD_DIR", "./uploads"),
 		BaseURL:      getEnv("BASE_URL", "http://localhost:8080"),
 		SentryDSN:    getEnv("SENTRY_DSN", ""),
+		SessionStore: getEnv("SESSION_STORE", "sqlite"),
+
+		RegistrationMode: getEnv("REGISTRATION_MODE", "open"),
+		AdminUsername:    getEnv("ADMIN_USERNAME", ""),
+		AdminPassword:    getEnv("ADMIN_PASSWORD", ""),
+
+		CORSOrigins:  getEnvList("CORS_ORIGINS", []string{"http://localhost", "http://localhost:5173"}),
 		MaxFileSize:  10 * 1024 * 1024, // 10MB
 		AllowedTypes: []string{"image/jpeg", "image/jpg", "image/png", "image/gif", "image/webp"},
+
+		Storage: LoadStorage(""),
+
+		ImageCacheControl:       getEnv("IMAGE_CACHE_CONTROL", "public, max-age=300"),
+		ImageContentDisposition: getEnv("IMAGE_CONTENT_DISPOSITION", ""),
+
+		DefaultVisibility: getEnv("DEFAULT_VISIBILITY", "public"),
+
+		URLSigningKeys:      getEnvSigningKeys("URL_SIGNING_KEYS"),
+		SignedURLDefaultTTL: time.Duration(getEnvInt("SIGNED_URL_DEFAULT_TTL_MINUTES", 60)) * time.Minute,
+		SignedURLMaxTTL:     time.Duration(getEnvInt("SIGNED_URL_MAX_TTL_HOURS", 168)) * time.Hour,
+
+		BatchMaxFiles: getEnvInt("BATCH_MAX_FILES", 20),
+		BatchMaxBytes: int64(getEnvInt("BATCH_MAX_TOTAL_MB", 100)) * 1024 * 1024,
+
+		MaxImageWidth:  getEnvInt("MAX_IMAGE_WIDTH", 12000),
+		MaxImageHeight: getEnvInt("MAX_IMAGE_HEIGHT", 12000),
+		MaxImagePixels: int64(getEnvInt("MAX_IMAGE_PIXELS", 50_000_000)),
+
+		Variants: getEnvVariants("IMAGE_VARIANTS", "thumb:256,medium:1024"),
+
+		ExifStrip:  getEnv("EXIF_STRIP", "gps"),
+		AutoRotate: getEnvBool("AUTO_ROTATE", true),
+
+		TransformSizes:         getEnvInts("TRANSFORM_SIZES", []int{64, 128, 256, 320, 400, 480, 640, 800, 1024, 1280, 1600, 1920}),
+		TransformCacheDir:      getEnv("TRANSFORM_CACHE_DIR", "./cache/transforms"),
+		TransformCacheMaxBytes: int64(getEnvInt("TRANSFORM_CACHE_MAX_MB", 512)) * 1024 * 1024,
+		TransformConcurrency:   getEnvInt("TRANSFORM_CONCURRENCY", runtime.NumCPU()),
+
+		TusUploadTTL: time.Duration(getEnvInt("TUS_UPLOAD_TTL_HOURS", 24)) * time.Hour,
+
+		DefaultQuota: Quota{
+			MaxBytes:  int64(getEnvInt("QUOTA_MAX_MB", 0)) * 1024 * 1024,
+			MaxImages: int64(getEnvInt("QUOTA_MAX_IMAGES", 0)),
+		},
+		RoleQuotas: getEnvRoleQuotas("QUOTA_ROLES"),
 	}
 }
 
+// LoadStorage читает настройки хранилища из переменных с префиксом prefix:
+// пустой для основного хранилища, TARGET_ — для цели переноса файлов
+func LoadStorage(prefix string) StorageConfig {
+	return StorageConfig{
+		Backend:  getEnv(prefix+"STORAGE_BACKEND", "local"),
+		LocalDir: getEnv(prefix+"UPLOAD_DIR", "./uploads"),
+
+		S3Endpoint:   getEnv(prefix+"S3_ENDPOINT", ""),
+		S3Region:     getEnv(prefix+"S3_REGION", "us-east-1"),
+		S3Bucket:     getEnv(prefix+"S3_BUCKET", ""),
+		S3AccessKey:  getEnv(prefix+"S3_ACCESS_KEY", ""),
+		S3SecretKey:  getEnv(prefix+"S3_SECRET_KEY", ""),
+		S3UseSSL:     getEnvBool(prefix+"S3_USE_SSL", true),
+		S3PathStyle:  getEnvBool(prefix+"S3_PATH_STYLE", false),
+		S3Prefix:     getEnv(prefix+"S3_PREFIX", ""),
+		S3PublicURL:  getEnv(prefix+"S3_PUBLIC_URL", ""),
+		S3PresignTTL: time.Duration(getEnvInt(prefix+"S3_PRESIGN_TTL_MINUTES", 60)) * time.Minute,
+	}
+}
+
+// Location однозначно описывает хранилище: по нему перенос файлов
+// отличает цели друг от друга и от источника
+func (c StorageConfig) Location() string {
+	if c.Backend == "s3" {
+		return "s3://" + c.S3Endpoint + "/" + c.S3Bucket + "/" + strings.Trim(c.S3Prefix, "/")
+	}
+	dir, err := filepath.Abs(c.LocalDir)
+	if err != nil {
+		dir = c.LocalDir
+	}
+	return c.Backend + ":" + dir
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInts(key string, defaultValue []int) []int {
+	var result []int
+	for _, item := range getEnvList(key, nil) {
+		if value, err := strconv.Atoi(item); err == nil && value > 0 {
+			result = append(result, value)
+		}
+	}
+	if len(result) == 0 {
+		return defaultValue
+	}
+	return result
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvVariants разбирает список вида "thumb:256,medium:1024".
+// Некорректные элементы пропускаются, значение "none" отключает варианты.
+func getEnvVariants(key, defaultValue string) []VariantSpec {
+	var variants []VariantSpec
+	for _, item := range getEnvList(key, strings.Split(defaultValue, ",")) {
+		name, size, ok := strings.Cut(item, ":")
+		maxSize, err := strconv.Atoi(size)
+		if !ok || name == "" || err != nil || maxSize <= 0 {
+			continue
+		}
+		variants = append(variants, VariantSpec{Name: name, MaxSize: maxSize})
+	}
+	return variants
+}
+
+// getEnvSigningKeys разбирает список вида "2024b:секрет,2024a:секрет".
+// Элементы без идентификатора или секрета пропускаются.
+func getEnvSigningKeys(key string) []SigningKey {
+	var keys []SigningKey
+	for _, item := range getEnvList(key, nil) {
+		id, secret, ok := strings.Cut(item, ":")
+		if !ok || id == "" || secret == "" {
+			continue
+		}
+		keys = append(keys, SigningKey{ID: id, Secret: secret})
+	}
+	return keys
+}
+
+// getEnvRoleQuotas разбирает список вида "user:1024:500,admin:0:0" —
+// роль, объем в мегабайтах и число изображений (0 — без ограничения).
+// Некорректные элементы пропускаются.
+func getEnvRoleQuotas(key string) map[string]Quota {
+	quotas := make(map[string]Quota)
+	for _, item := range getEnvList(key, nil) {
+		parts := strings.Split(item, ":")
+		if len(parts) != 3 || parts[0] == "" {
+			continue
+		}
+		megabytes, err1 := strconv.ParseInt(parts[1], 10, 64)
+		images, err2 := strconv.ParseInt(parts[2], 10, 64)
+		if err1 != nil || err2 != nil || megabytes < 0 || images < 0 {
+			continue
+		}
+		quotas[parts[0]] = Quota{MaxBytes: megabytes * 1024 * 1024, MaxImages: images}
+	}
+	return quotas
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"image-uploader-backend/internal/middleware"
 	"image-uploader-backend/internal/models"
 	"image-uploader-backend/internal/repository"
 	"image-uploader-backend/internal/service"
@@ -10,44 +12,289 @@ import (
 )
 
 type AdminHandler struct {
-	imageService *service.ImageService
-	userRepo     *repository.UserRepository
+	imageService      *service.ImageService
+	shareService      *service.ShareService
+	userService       *service.UserService
+	moderationService *service.ModerationService
+	userRepo          *repository.UserRepository
 }
 
-func NewAdminHandler(imageService *service.ImageService, userRepo *repository.UserRepository) *AdminHandler {
+func NewAdminHandler(imageService *service.ImageService, shareService *service.ShareService, userService *service.UserService,
+	moderationService *service.ModerationService, userRepo *repository.UserRepository) *AdminHandler {
 	return &AdminHandler{
-		imageService: imageService,
-		userRepo:     userRepo,
+		imageService:      imageService,
+		shareService:      shareService,
+		userService:       userService,
+		moderationService: moderationService,
+		userRepo:          userRepo,
 	}
 }
 
+// GetUsers возвращает страницу пользователей; q — поиск по части имени,
+// сортировка по дате регистрации или имени
 func (h *AdminHandler) GetUsers(c echo.Context) error {
-	users, err := h.userRepo.GetAllWithImageCount()
+	page, err := pageRequest(c, models.SortCreatedAt, models.SortName)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "Failed to get users",
-			Code:  "GET_ERROR",
-		})
+		return validationError(c, err)
+	}
+
+	users, err := h.userRepo.ListWithImageCount(models.UserFilter{Query: c.QueryParam("q")}, page)
+	if err != nil {
+		return listError(c, err, "Failed to get users")
 	}
 
 	// Убираем пароли из ответа
-	for i := range users {
-		users[i].PasswordHash = ""
+	for i := range users.Items {
+		users.Items[i].PasswordHash = ""
 	}
 
 	return c.JSON(http.StatusOK, users)
 }
 
+// CreateUser создает пользователя с любой ролью, в том числе при закрытой регистрации
+func (h *AdminHandler) CreateUser(c echo.Context) error {
+	var req models.CreateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	user, err := h.userService.Create(req)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, user)
+}
+
+// UpdateUser меняет роль пользователя или отключает и включает его учетную запись
+func (h *AdminHandler) UpdateUser(c echo.Context) error {
+	var req models.UpdateUserRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	user, err := h.userService.Update(middleware.GetCurrentUser(c), c.Param("id"), req)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, user)
+}
+
+// ResetPassword задает пользователю временный пароль, который нужно сменить
+// перед входом, и завершает его сессии
+func (h *AdminHandler) ResetPassword(c echo.Context) error {
+	var req models.ResetPasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	password, err := h.userService.ResetPassword(c.Param("id"), req.Password)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.ResetPasswordResponse{Password: password})
+}
+
+// DeleteUser удаляет пользователя; images=delete удаляет его изображения,
+// images=reassign&reassign_to=<id> передает их другому пользователю
+func (h *AdminHandler) DeleteUser(c echo.Context) error {
+	err := h.userService.Delete(middleware.GetCurrentUser(c), c.Param("id"), c.QueryParam("images"), c.QueryParam("reassign_to"))
+	if err != nil {
+		return userError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func userError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "User not found",
+			Code:  "NOT_FOUND",
+		})
+	case errors.Is(err, service.ErrInvalidUser):
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	case errors.Is(err, service.ErrUsernameExists):
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "USERNAME_EXISTS",
+		})
+	case errors.Is(err, service.ErrSelfModification):
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "SELF_MODIFICATION",
+		})
+	case errors.Is(err, service.ErrUserHasImages):
+		return c.JSON(http.StatusConflict, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "USER_HAS_IMAGES",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to update user",
+			Code:  "USER_ERROR",
+		})
+	}
+}
+
 func (h *AdminHandler) GetUserImages(c echo.Context) error {
 	userID := c.Param("id")
 
-	images, err := h.imageService.GetByUserID(userID)
+	filter, err := imageFilter(c)
+	if err != nil {
+		return validationError(c, err)
+	}
+	filter.UserID = userID
+	page, err := imagePage(c)
+	if err != nil {
+		return validationError(c, err)
+	}
+
+	images, err := h.imageService.List(filter, page)
+	if err != nil {
+		return listError(c, err, "Failed to get user images")
+	}
+
+	return c.JSON(http.StatusOK, images)
+}
+
+// ListImages возвращает страницу изображений всех пользователей с именами
+// владельцев: отбор как у списка своих изображений, а также по user_id,
+// hidden и flagged
+func (h *AdminHandler) ListImages(c echo.Context) error {
+	filter, err := imageFilter(c)
+	if err != nil {
+		return validationError(c, err)
+	}
+	filter.UserID = c.QueryParam("user_id")
+	if filter.Hidden, err = queryFlag(c, "hidden"); err != nil {
+		return validationError(c, err)
+	}
+	if filter.Flagged, err = queryFlag(c, "flagged"); err != nil {
+		return validationError(c, err)
+	}
+	page, err := imagePage(c)
+	if err != nil {
+		return validationError(c, err)
+	}
+
+	images, err := h.moderationService.List(filter, page)
+	if err != nil {
+		return listError(c, err, "Failed to get images")
+	}
+
+	return c.JSON(http.StatusOK, images)
+}
+
+// BulkImages применяет действие к нескольким изображениям. Каждое
+// изображение обрабатывается независимо, ошибка одного не отменяет остальные.
+func (h *AdminHandler) BulkImages(c echo.Context) error {
+	var req models.BulkImageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	if err := h.moderationService.Check(req); err != nil {
+		if errors.Is(err, service.ErrInvalidModeration) || errors.Is(err, service.ErrInvalidVisibility) {
+			return validationError(c, err)
+		}
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to process images",
+			Code:  "MODERATION_ERROR",
+		})
+	}
+
+	response := &models.BulkImageResponse{Results: []models.BulkImageResult{}}
+	for _, id := range req.ImageIDs {
+		result := models.BulkImageResult{ID: id, Success: true}
+		if err := h.moderationService.Apply(req, id); err != nil {
+			result.Success = false
+			result.Error = moderationError(err)
+		}
+
+		response.Results = append(response.Results, result)
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	response.Total = len(response.Results)
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// moderationError описывает ошибку действия над одним изображением
+func moderationError(err error) *models.ErrorResponse {
+	if errors.Is(err, service.ErrImageNotFound) {
+		return &models.ErrorResponse{
+			Error: "Image not found",
+			Code:  "NOT_FOUND",
+		}
+	}
+	return &models.ErrorResponse{
+		Error: "Failed to process image",
+		Code:  "MODERATION_ERROR",
+	}
+}
+
+func (h *AdminHandler) DeleteImage(c echo.Context) error {
+	image, err := h.imageService.GetByID(c.Param("id"))
+	if errors.Is(err, service.ErrImageNotFound) {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Image not found",
+			Code:  "NOT_FOUND",
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error: "Failed to get user images",
+			Error: "Failed to get image",
 			Code:  "GET_ERROR",
 		})
 	}
 
-	return c.JSON(http.StatusOK, images)
+	if err := h.imageService.Delete(image); err != nil {
+		return deleteImageError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetShareLinks возвращает ссылки всех пользователей с владельцами и просмотрами
+func (h *AdminHandler) GetShareLinks(c echo.Context) error {
+	links, err := h.shareService.ListAll()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get share links",
+			Code:  "GET_ERROR",
+		})
+	}
+
+	if links == nil {
+		links = []*models.ShareLink{}
+	}
+	return c.JSON(http.StatusOK, links)
+}
+
+// RevokeShareLink отзывает любую ссылку
+func (h *AdminHandler) RevokeShareLink(c echo.Context) error {
+	return revokeShareLink(c, h.shareService.Revoke(c.Param("id"), ""))
 }
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AlbumHandler — альбомы пользователя: управление владельцем и просмотр
+// с учетом видимости альбома
+type AlbumHandler struct {
+	albumService *service.AlbumService
+}
+
+func NewAlbumHandler(albumService *service.AlbumService) *AlbumHandler {
+	return &AlbumHandler{
+		albumService: albumService,
+	}
+}
+
+func (h *AlbumHandler) CreateAlbum(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	var req models.CreateAlbumRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	album, err := h.albumService.Create(user.ID, req)
+	if err != nil {
+		return albumError(c, err)
+	}
+
+	return c.JSON(http.StatusCreated, album)
+}
+
+func (h *AlbumHandler) ListAlbums(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	albums, err := h.albumService.ListByUser(user.ID)
+	if err != nil {
+		return albumError(c, err)
+	}
+
+	if albums == nil {
+		albums = []*models.Album{}
+	}
+	return c.JSON(http.StatusOK, albums)
+}
+
+// GetAlbum возвращает альбом с изображениями любому, кому он виден;
+// для unlisted альбома нужен токен ?t= из ссылки
+func (h *AlbumHandler) GetAlbum(c echo.Context) error {
+	album, err := h.albumService.View(c.Param("id"), middleware.GetCurrentUser(c), c.QueryParam("t"))
+	if err != nil {
+		return albumError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, album)
+}
+
+// UpdateAlbum меняет название, описание, видимость или обложку
+func (h *AlbumHandler) UpdateAlbum(c echo.Context) error {
+	var req models.UpdateAlbumRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	album, err := h.loadOwnAlbum(c)
+	if album == nil {
+		return err
+	}
+
+	if err := h.albumService.Update(album, req); err != nil {
+		// Обложка не из альбома — ошибка запроса, а не отсутствующий ресурс
+		if errors.Is(err, service.ErrNotInAlbum) {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "cover image must belong to the album",
+				Code:  "VALIDATION_ERROR",
+			})
+		}
+		return albumError(c, err)
+	}
+
+	return h.respondAlbum(c, album)
+}
+
+func (h *AlbumHandler) DeleteAlbum(c echo.Context) error {
+	album, err := h.loadOwnAlbum(c)
+	if album == nil {
+		return err
+	}
+
+	if err := h.albumService.Delete(album); err != nil {
+		return albumError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AddImages добавляет собственные изображения в конец альбома
+func (h *AlbumHandler) AddImages(c echo.Context) error {
+	var req models.AlbumImagesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	album, err := h.loadOwnAlbum(c)
+	if album == nil {
+		return err
+	}
+
+	if err := h.albumService.AddImages(album, req.ImageIDs); err != nil {
+		return albumError(c, err)
+	}
+
+	return h.respondAlbum(c, album)
+}
+
+func (h *AlbumHandler) RemoveImage(c echo.Context) error {
+	album, err := h.loadOwnAlbum(c)
+	if album == nil {
+		return err
+	}
+
+	if err := h.albumService.RemoveImage(album, c.Param("image_id")); err != nil {
+		return albumError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ReorderImages задает порядок изображений: полный список id альбома
+func (h *AlbumHandler) ReorderImages(c echo.Context) error {
+	var req models.AlbumImagesRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	album, err := h.loadOwnAlbum(c)
+	if album == nil {
+		return err
+	}
+
+	if err := h.albumService.Reorder(album, req.ImageIDs); err != nil {
+		return albumError(c, err)
+	}
+
+	return h.respondAlbum(c, album)
+}
+
+// respondAlbum отвечает альбомом с изображениями после изменения
+func (h *AlbumHandler) respondAlbum(c echo.Context, album *models.Album) error {
+	album, err := h.albumService.View(album.ID, middleware.GetCurrentUser(c), "")
+	if err != nil {
+		return albumError(c, err)
+	}
+	return c.JSON(http.StatusOK, album)
+}
+
+// loadOwnAlbum загружает альбом из параметра :id, принадлежащий текущему
+// пользователю. Если альбома нет, ответ с ошибкой уже отправлен и
+// возвращается nil вместе с результатом отправки.
+func (h *AlbumHandler) loadOwnAlbum(c echo.Context) (*models.Album, error) {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return nil, c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	album, err := h.albumService.GetOwn(c.Param("id"), user.ID)
+	if err != nil {
+		return nil, albumError(c, err)
+	}
+	return album, nil
+}
+
+func albumError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAlbumNotFound):
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Album not found",
+			Code:  "NOT_FOUND",
+		})
+	case errors.Is(err, service.ErrImageNotFound), errors.Is(err, service.ErrNotInAlbum):
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Image not found",
+			Code:  "NOT_FOUND",
+		})
+	case errors.Is(err, service.ErrInvalidAlbum), errors.Is(err, service.ErrInvalidAlbumOrder),
+		errors.Is(err, service.ErrInvalidVisibility):
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to process album",
+			Code:  "ALBUM_ERROR",
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"image-uploader-backend/internal/middleware"
 	"image-uploader-backend/internal/models"
 	"image-uploader-backend/internal/service"
@@ -44,20 +45,25 @@ func (h *AuthHandler) Register(c echo.Context) error {
 		})
 	}
 
-	// Устанавливаем роль по умолчанию
-	if req.Role == "" {
-		req.Role = "user"
-	}
-
-	// Регистрация
-	user, err := h.authService.Register(req.Username, req.Password, req.Role)
+	// Регистрация. Роль из тела запроса не принимается: её задаёт только инвайт
+	user, err := h.authService.Register(req.Username, req.Password, req.InviteCode)
 	if err != nil {
 		// Проверяем тип ошибки для более детального сообщения
+		status := http.StatusBadRequest
 		errorCode := "REGISTRATION_ERROR"
-		if err.Error() == "username already exists" {
+		switch {
+		case errors.Is(err, service.ErrUsernameExists):
 			errorCode = "USERNAME_EXISTS"
+		case errors.Is(err, service.ErrRegistrationClosed):
+			status = http.StatusForbidden
+			errorCode = "REGISTRATION_CLOSED"
+		case errors.Is(err, service.ErrInviteRequired):
+			status = http.StatusForbidden
+			errorCode = "INVITE_REQUIRED"
+		case errors.Is(err, service.ErrInvalidInvite):
+			errorCode = "INVALID_INVITE"
 		}
-		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		return c.JSON(status, models.ErrorResponse{
 			Error: err.Error(),
 			Code:  errorCode,
 		})
@@ -68,6 +74,13 @@ func (h *AuthHandler) Register(c echo.Context) error {
 	})
 }
 
+// GetRegistration сообщает фронтенду текущий режим регистрации
+func (h *AuthHandler) GetRegistration(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.RegistrationInfo{
+		Mode: h.authService.RegistrationMode(),
+	})
+}
+
 func (h *AuthHandler) Login(c echo.Context) error {
 	var req models.LoginRequest
 	if err := c.Bind(&req); err != nil {
@@ -78,19 +91,16 @@ func (h *AuthHandler) Login(c echo.Context) error {
 	}
 
 	// Логин
-	sessionID, user, err := h.authService.Login(req.Username, req.Password)
+	sessionID, user, err := h.authService.Login(req.Username, req.Password, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
-		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
-			Error: err.Error(),
-			Code:  "LOGIN_ERROR",
-		})
+		return loginError(c, err)
 	}
 
 	// Устанавливаем cookie
 	cookie := new(http.Cookie)
 	cookie.Name = "session_id"
 	cookie.Value = sessionID
-	cookie.Expires = time.Now().Add(24 * time.Hour)
+	cookie.Expires = time.Now().Add(service.SessionTTL)
 	cookie.HttpOnly = true
 	cookie.Path = "/"
 	cookie.SameSite = http.SameSiteLaxMode
@@ -121,6 +131,59 @@ func (h *AuthHandler) Logout(c echo.Context) error {
 	})
 }
 
+// ChangePassword меняет пароль по текущему; доступно без сессии, в том
+// числе после сброса пароля администратором. Все сессии завершаются.
+func (h *AuthHandler) ChangePassword(c echo.Context) error {
+	var req models.ChangePasswordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	err := h.authService.ChangePassword(req.Username, req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, service.ErrInvalidUser) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	}
+	if err != nil {
+		return loginError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]string{
+		"message": "Password changed successfully",
+	})
+}
+
+// loginError отвечает на ошибку проверки имени и пароля
+func loginError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAccountDisabled):
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "ACCOUNT_DISABLED",
+		})
+	case errors.Is(err, service.ErrPasswordResetRequired):
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "PASSWORD_RESET_REQUIRED",
+		})
+	case errors.Is(err, service.ErrInvalidCredentials):
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "LOGIN_ERROR",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to process login",
+			Code:  "LOGIN_ERROR",
+		})
+	}
+}
+
 func (h *AuthHandler) GetMe(c echo.Context) error {
 	user := middleware.GetCurrentUser(c)
 	if user == nil {
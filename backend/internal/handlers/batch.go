@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// batchFileFields — имена полей с файлами в пакетной загрузке
+var batchFileFields = map[string]bool{"images[]": true, "images": true}
+
+// UploadBatch принимает несколько файлов в полях images[] одного запроса.
+// Тело читается потоком: в памяти файлы не держатся, текущий файл копируется
+// во временный и удаляется после сохранения. Каждый файл проверяется и
+// сохраняется независимо, ошибка одного не отменяет остальные. Неверное
+// значение обычного поля прерывает обработку: следующие файлы сохранились
+// бы не с теми параметрами.
+func (h *UploadHandler) UploadBatch(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	reader, err := c.Request().MultipartReader()
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Request must be multipart/form-data",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	response := &models.BatchUploadResponse{Results: []models.BatchUploadResult{}}
+	opts := service.SaveOptions{}
+	var totalBytes int64
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Обработанные файлы уже сохранены, поэтому возвращаем их результаты
+			response.Error = batchStreamError(err)
+			break
+		}
+
+		// Обычные поля влияют только на файлы, идущие после них
+		if part.FileName() == "" {
+			if response.Error = h.batchOption(part, &opts, user.ID); response.Error != nil {
+				break
+			}
+			continue
+		}
+		if !batchFileFields[part.FormName()] {
+			continue
+		}
+
+		result := models.BatchUploadResult{
+			Index:    len(response.Results),
+			Filename: part.FileName(),
+		}
+
+		if len(response.Results) >= h.limits.MaxFiles {
+			result.Error = &models.ErrorResponse{
+				Error: fmt.Sprintf("batch exceeds maximum of %d files", h.limits.MaxFiles),
+				Code:  "TOO_MANY_FILES",
+			}
+		} else {
+			var size int64
+			result.Image, size, result.Error = h.saveBatchPart(part, user.ID, opts, h.limits.MaxBytes-totalBytes)
+			totalBytes += size
+		}
+
+		result.Success = result.Error == nil
+		response.Results = append(response.Results, result)
+	}
+
+	for _, result := range response.Results {
+		if result.Success {
+			response.Succeeded++
+		} else {
+			response.Failed++
+		}
+	}
+	response.Total = len(response.Results)
+
+	// Если до неверного поля не было файлов, ошибка относится ко всему запросу
+	if response.Total == 0 && response.Error != nil &&
+		(response.Error.Code == "VALIDATION_ERROR" || response.Error.Code == "FIELD_TOO_LARGE") {
+		return c.JSON(http.StatusBadRequest, response.Error)
+	}
+	if response.Total == 0 && response.Error == nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "No image files provided",
+			Code:  "NO_FILE",
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// batchOption применяет обычное поле формы к параметрам следующих файлов
+// и проверяет их. Неизвестные поля пропускаются.
+func (h *UploadHandler) batchOption(part *multipart.Part, opts *service.SaveOptions, userID string) *models.ErrorResponse {
+	limits := map[string]int64{"dedupe": 16, "visibility": 16, "album": 64, "tags": 4096, "caption": 4096}
+	limit, ok := limits[part.FormName()]
+	if !ok {
+		return nil
+	}
+	// Читаем на байт больше лимита: обрезанное значение сохранилось бы молча
+	value, err := io.ReadAll(io.LimitReader(part, limit+1))
+	if err != nil {
+		return batchStreamError(err)
+	}
+	if int64(len(value)) > limit {
+		return &models.ErrorResponse{
+			Error: fmt.Sprintf("%s must be at most %d bytes", part.FormName(), limit),
+			Code:  "FIELD_TOO_LARGE",
+		}
+	}
+
+	switch part.FormName() {
+	case "dedupe":
+		dedupe, err := strconv.ParseBool(string(value))
+		if err != nil && len(value) > 0 {
+			return &models.ErrorResponse{
+				Error: "dedupe must be true or false",
+				Code:  "VALIDATION_ERROR",
+			}
+		}
+		opts.Dedupe = dedupe
+		return nil
+	case "visibility":
+		opts.Visibility = string(value)
+	case "album":
+		opts.AlbumID = string(value)
+	case "tags":
+		opts.Tags = service.ParseTags(string(value))
+	case "caption":
+		opts.Caption = string(value)
+	}
+
+	err = h.imageService.CheckOptions(*opts, userID)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrAlbumNotFound):
+		return &models.ErrorResponse{
+			Error: "album not found",
+			Code:  "VALIDATION_ERROR",
+		}
+	case errors.Is(err, service.ErrInvalidVisibility), errors.Is(err, service.ErrInvalidTags),
+		errors.Is(err, service.ErrInvalidCaption):
+		return &models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		}
+	default:
+		return &models.ErrorResponse{
+			Error: "Failed to check upload options",
+			Code:  "SAVE_ERROR",
+		}
+	}
+}
+
+// saveBatchPart читает один файл из потока, проверяет и сохраняет его.
+// budget — сколько байт еще можно принять в рамках запроса. Возвращает
+// число байт файла, учтенных в общем лимите.
+func (h *UploadHandler) saveBatchPart(part *multipart.Part, userID string, opts service.SaveOptions, budget int64) (*models.UploadResponse, int64, *models.ErrorResponse) {
+	// Проверке и обработке нужен произвольный доступ к содержимому,
+	// поэтому файл сначала копируется на диск
+	tmp, err := os.CreateTemp("", "batch-upload-*")
+	if err != nil {
+		return nil, 0, &models.ErrorResponse{
+			Error: "Failed to save image",
+			Code:  "SAVE_ERROR",
+		}
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	limit := min(h.limits.MaxFileSize, max(budget, 0))
+	// Читаем на байт больше лимита, чтобы отличить файл ровно по лимиту от большего
+	size, err := io.Copy(tmp, io.LimitReader(part, limit+1))
+	if err != nil {
+		return nil, size, &models.ErrorResponse{
+			Error: "Failed to read file",
+			Code:  "INVALID_REQUEST",
+		}
+	}
+
+	if size > limit {
+		// Остаток файла пропустит NextPart
+		if limit < h.limits.MaxFileSize {
+			return nil, size, &models.ErrorResponse{
+				Error: fmt.Sprintf("batch exceeds maximum total size of %d bytes", h.limits.MaxBytes),
+				Code:  "BATCH_TOO_LARGE",
+			}
+		}
+		return nil, size, &models.ErrorResponse{
+			Error: fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", h.limits.MaxFileSize),
+			Code:  "VALIDATION_ERROR",
+		}
+	}
+
+	info, err := h.imageService.ValidateContent(tmp, size, part.Header.Get(echo.HeaderContentType))
+	if err != nil {
+		return nil, size, &models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		}
+	}
+
+	image, duplicate, err := h.imageService.SaveContent(tmp, size, part.FileName(), info, userID, opts)
+	if errors.Is(err, service.ErrInvalidVisibility) || errors.Is(err, service.ErrInvalidTags) {
+		return nil, size, &models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		}
+	}
+	if errors.Is(err, service.ErrAlbumNotFound) {
+		return nil, size, &models.ErrorResponse{
+			Error: "Album not found",
+			Code:  "NOT_FOUND",
+		}
+	}
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		return nil, size, &models.ErrorResponse{
+			Error: "Storage quota exceeded",
+			Code:  "QUOTA_EXCEEDED",
+		}
+	}
+	if err != nil {
+		return nil, size, &models.ErrorResponse{
+			Error: "Failed to save image",
+			Code:  "SAVE_ERROR",
+		}
+	}
+
+	return uploadResponse(image, duplicate), size, nil
+}
+
+// batchStreamError описывает ошибку чтения тела, прервавшую обработку
+func batchStreamError(err error) *models.ErrorResponse {
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) && httpErr.Code == http.StatusRequestEntityTooLarge {
+		return &models.ErrorResponse{
+			Error: "Request body is too large",
+			Code:  "BATCH_TOO_LARGE",
+		}
+	}
+	return &models.ErrorResponse{
+		Error: "Malformed multipart body",
+		Code:  "INVALID_REQUEST",
+	}
+}
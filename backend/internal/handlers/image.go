@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ImageHandler — операции пользователя над собственными изображениями
+type ImageHandler struct {
+	imageService *service.ImageService
+}
+
+func NewImageHandler(imageService *service.ImageService) *ImageHandler {
+	return &ImageHandler{
+		imageService: imageService,
+	}
+}
+
+// ListImages возвращает страницу своих изображений с отбором по imageFilter
+// и сортировкой по времени загрузки, размеру или имени
+func (h *ImageHandler) ListImages(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	filter, err := imageFilter(c)
+	if err != nil {
+		return validationError(c, err)
+	}
+	filter.UserID = user.ID
+	page, err := imagePage(c)
+	if err != nil {
+		return validationError(c, err)
+	}
+
+	images, err := h.imageService.List(filter, page)
+	if err != nil {
+		return listError(c, err, "Failed to get images")
+	}
+
+	return c.JSON(http.StatusOK, images)
+}
+
+// Search ищет изображения по имени файла, подписи и тегам: ?q= — слова
+// (префиксы), from/to — интервал дат загрузки, limit — число результатов.
+// Администратор ищет по всем пользователям или по user_id.
+func (h *ImageHandler) Search(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	from, err := queryTime(c, "from", false)
+	if err != nil {
+		return validationError(c, err)
+	}
+	to, err := queryTime(c, "to", true)
+	if err != nil {
+		return validationError(c, err)
+	}
+	limit := 20
+	if value := c.QueryParam("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return validationError(c, errors.New("limit must be a positive number"))
+		}
+	}
+
+	results, err := h.imageService.Search(user, c.QueryParam("q"), c.QueryParam("user_id"), from, to, limit)
+	if errors.Is(err, service.ErrInvalidSearch) {
+		return validationError(c, err)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to search images",
+			Code:  "SEARCH_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *ImageHandler) GetImage(c echo.Context) error {
+	image, err := h.loadOwnImage(c)
+	if image == nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, image)
+}
+
+// UpdateImage меняет видимость и теги изображения
+func (h *ImageHandler) UpdateImage(c echo.Context) error {
+	var req models.UpdateImageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	image, err := h.loadOwnImage(c)
+	if image == nil {
+		return err
+	}
+
+	if err := h.imageService.Update(image, req); err != nil {
+		if errors.Is(err, service.ErrInvalidVisibility) || errors.Is(err, service.ErrInvalidTags) ||
+			errors.Is(err, service.ErrInvalidCaption) {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "VALIDATION_ERROR",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to update image",
+			Code:  "UPDATE_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusOK, image)
+}
+
+// SignURL выдает подписанную ссылку на файл изображения с ограниченным сроком
+func (h *ImageHandler) SignURL(c echo.Context) error {
+	var req models.SignURLRequest
+	if err := c.Bind(&req); err != nil || req.TTLSeconds < 0 {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	image, err := h.loadOwnImage(c)
+	if image == nil {
+		return err
+	}
+
+	url, expiresAt, err := h.imageService.SignURL(image, req.Variant, time.Duration(req.TTLSeconds)*time.Second)
+	switch {
+	case errors.Is(err, service.ErrInvalidTTL), errors.Is(err, service.ErrUnknownVariant):
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	case errors.Is(err, service.ErrSigningDisabled):
+		return c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "SIGNING_DISABLED",
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to sign URL",
+			Code:  "SIGN_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusOK, models.SignedURLResponse{
+		URL:       url,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (h *ImageHandler) DeleteImage(c echo.Context) error {
+	image, err := h.loadOwnImage(c)
+	if image == nil {
+		return err
+	}
+
+	if err := h.imageService.Delete(image); err != nil {
+		return deleteImageError(c, err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// loadOwnImage загружает изображение из параметра :id, принадлежащее текущему
+// пользователю. Если изображения нет, ответ с ошибкой уже отправлен и
+// возвращается nil вместе с результатом отправки.
+func (h *ImageHandler) loadOwnImage(c echo.Context) (*models.Image, error) {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return nil, c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	image, err := h.imageService.GetByID(c.Param("id"))
+	// Чужое изображение выглядит так же, как несуществующее
+	if errors.Is(err, service.ErrImageNotFound) || (err == nil && image.UserID != user.ID) {
+		return nil, c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Image not found",
+			Code:  "NOT_FOUND",
+		})
+	}
+	if err != nil {
+		return nil, c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get image",
+			Code:  "GET_ERROR",
+		})
+	}
+
+	return image, nil
+}
+
+// imageFilter разбирает параметры отбора списка изображений: tags=a,b
+// с match=all (по умолчанию — все теги) или match=any (хотя бы один),
+// type — MIME типы через запятую, from/to — даты загрузки, min_size/max_size
+// — размер в байтах
+func imageFilter(c echo.Context) (models.ImageFilter, error) {
+	var matchAll bool
+	switch c.QueryParam("match") {
+	case "", "all":
+		matchAll = true
+	case "any":
+	default:
+		return models.ImageFilter{}, errors.New("match must be all or any")
+	}
+
+	filter, err := service.NewImageFilter(service.ParseTags(c.QueryParam("tags")), matchAll)
+	if err != nil {
+		return filter, err
+	}
+
+	for _, mimeType := range strings.Split(c.QueryParam("type"), ",") {
+		if mimeType = strings.ToLower(strings.TrimSpace(mimeType)); mimeType != "" {
+			filter.MimeTypes = append(filter.MimeTypes, service.NormalizeMimeType(mimeType))
+		}
+	}
+	if filter.From, err = queryTime(c, "from", false); err != nil {
+		return filter, err
+	}
+	if filter.To, err = queryTime(c, "to", true); err != nil {
+		return filter, err
+	}
+	if filter.MinSize, err = querySize(c, "min_size"); err != nil {
+		return filter, err
+	}
+	if filter.MaxSize, err = querySize(c, "max_size"); err != nil {
+		return filter, err
+	}
+	if filter.MaxSize > 0 && filter.MinSize > filter.MaxSize {
+		return filter, errors.New("min_size must not exceed max_size")
+	}
+
+	return filter, nil
+}
+
+// imagePage разбирает параметры страницы списка изображений
+func imagePage(c echo.Context) (models.PageRequest, error) {
+	return pageRequest(c, models.SortCreatedAt, models.SortSize, models.SortName)
+}
+
+// queryTime разбирает параметр запроса с датой (2006-01-02) или моментом
+// времени (RFC 3339). Дата в верхней границе (end) включается целиком,
+// поэтому превращается в начало следующего дня. Пустой параметр — нулевое время.
+func queryTime(c echo.Context, name string, end bool) (time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date (YYYY-MM-DD) or RFC 3339 time", name)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func validationError(c echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error: err.Error(),
+		Code:  "VALIDATION_ERROR",
+	})
+}
+
+func deleteImageError(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrImageNotFound) {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Image not found",
+			Code:  "NOT_FOUND",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error: "Failed to delete image",
+		Code:  "DELETE_ERROR",
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type InviteHandler struct {
+	authService *service.AuthService
+}
+
+func NewInviteHandler(authService *service.AuthService) *InviteHandler {
+	return &InviteHandler{
+		authService: authService,
+	}
+}
+
+func (h *InviteHandler) CreateInvite(c echo.Context) error {
+	admin := middleware.GetCurrentUser(c)
+	if admin == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	var req models.CreateInviteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	invite, err := h.authService.CreateInvite(admin.ID, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, invite)
+}
+
+func (h *InviteHandler) ListInvites(c echo.Context) error {
+	invites, err := h.authService.ListInvites()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get invites",
+			Code:  "GET_ERROR",
+		})
+	}
+
+	if invites == nil {
+		invites = []*models.Invite{}
+	}
+	return c.JSON(http.StatusOK, invites)
+}
+
+func (h *InviteHandler) RevokeInvite(c echo.Context) error {
+	err := h.authService.RevokeInvite(c.Param("id"))
+	if errors.Is(err, service.ErrInviteNotFound) {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Invite not found",
+			Code:  "NOT_FOUND",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to revoke invite",
+			Code:  "REVOKE_ERROR",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
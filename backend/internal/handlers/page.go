@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// pageRequest разбирает параметры страницы списка: sort (одно из sorts,
+// по умолчанию первое), order (asc или desc), limit и cursor
+func pageRequest(c echo.Context, sorts ...string) (models.PageRequest, error) {
+	page := models.PageRequest{
+		Sort:   sorts[0],
+		Limit:  defaultPageLimit,
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	if sort := c.QueryParam("sort"); sort != "" {
+		if !slices.Contains(sorts, sort) {
+			return page, fmt.Errorf("sort must be one of %s", strings.Join(sorts, ", "))
+		}
+		page.Sort = sort
+	}
+
+	switch c.QueryParam("order") {
+	case "", "desc":
+	case "asc":
+		page.Asc = true
+	default:
+		return page, errors.New("order must be asc or desc")
+	}
+
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = limit
+	}
+
+	return page, nil
+}
+
+// querySize разбирает неотрицательный размер в байтах; пусто — 0
+func querySize(c echo.Context, name string) (int64, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return 0, nil
+	}
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number of bytes", name)
+	}
+	return size, nil
+}
+
+// queryFlag разбирает необязательный логический параметр; пусто — nil
+func queryFlag(c echo.Context, name string) (*bool, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	flag, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", name)
+	}
+	return &flag, nil
+}
+
+// listError отвечает на ошибку получения страницы списка
+func listError(c echo.Context, err error, message string) error {
+	if errors.Is(err, service.ErrInvalidCursor) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid or outdated cursor",
+			Code:  "INVALID_CURSOR",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error: message,
+		Code:  "GET_ERROR",
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// QuotaHandler — использование хранилища: свое для пользователя и квоты
+// любых пользователей для администратора
+type QuotaHandler struct {
+	quotaService *service.QuotaService
+}
+
+func NewQuotaHandler(quotaService *service.QuotaService) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: quotaService,
+	}
+}
+
+// GetQuota возвращает использование хранилища текущим пользователем и его лимиты
+func (h *QuotaHandler) GetQuota(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	usage, err := h.quotaService.Usage(user.ID)
+	if err != nil {
+		return quotaError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, usage)
+}
+
+// GetUserQuota возвращает квоту пользователя :id вместе с лимитами,
+// заданными администратором
+func (h *QuotaHandler) GetUserQuota(c echo.Context) error {
+	quota, err := h.quotaService.Get(c.Param("id"))
+	if err != nil {
+		return quotaError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, quota)
+}
+
+// SetUserQuota задает пользователю :id свои лимиты; null в поле
+// возвращает лимит по умолчанию
+func (h *QuotaHandler) SetUserQuota(c echo.Context) error {
+	var req models.QuotaOverride
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	quota, err := h.quotaService.SetOverride(c.Param("id"), req)
+	if err != nil {
+		return quotaError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, quota)
+}
+
+func quotaError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "User not found",
+			Code:  "NOT_FOUND",
+		})
+	case errors.Is(err, service.ErrInvalidQuota):
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get quota",
+			Code:  "QUOTA_ERROR",
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"image-uploader-backend/internal/storage"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ServeOptions — заголовки ответов при отдаче файлов изображений
+type ServeOptions struct {
+	CacheControl string
+
+	// ContentDisposition — inline или attachment с исходным именем файла;
+	// пусто — заголовок не отправляется. Запрос с ?download=1 всегда
+	// получает attachment.
+	ContentDisposition string
+}
+
+// ServeHandler отдает файлы изображений по /images/<ключ>. Файл ищется
+// в базе, поэтому посторонние файлы в хранилище недоступны. Поддерживаются
+// условные запросы (ETag, Last-Modified) и диапазоны (Range).
+type ServeHandler struct {
+	imageService *service.ImageService
+	opts         ServeOptions
+}
+
+func NewServeHandler(imageService *service.ImageService, opts ServeOptions) *ServeHandler {
+	return &ServeHandler{
+		imageService: imageService,
+		opts:         opts,
+	}
+}
+
+func (h *ServeHandler) Serve(c echo.Context) error {
+	file, err := findFile(h.imageService, c)
+	if err != nil {
+		return serveError(c, err)
+	}
+
+	return h.serveFile(c, file, fileCacheControl(file, h.opts.CacheControl))
+}
+
+// serveFile отдает найденный файл с заголовками кэширования cacheControl
+func (h *ServeHandler) serveFile(c echo.Context, file *models.StoredFile, cacheControl string) error {
+	r, err := h.imageService.OpenFile(c.Request().Context(), file)
+	if err != nil {
+		return serveError(c, err)
+	}
+	defer r.Close()
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, file.MimeType)
+	header.Set("ETag", fileETag(file))
+	header.Set("X-Content-Type-Options", "nosniff")
+	if cacheControl != "" {
+		header.Set("Cache-Control", cacheControl)
+	}
+
+	disposition := h.opts.ContentDisposition
+	if download, _ := strconv.ParseBool(c.QueryParam("download")); download {
+		disposition = "attachment"
+	}
+	if disposition != "" {
+		header.Set(echo.HeaderContentDisposition, contentDisposition(disposition, file))
+	}
+
+	// ServeContent обрабатывает If-None-Match, If-Modified-Since, Range и HEAD
+	http.ServeContent(c.Response(), c.Request(), "", file.CreatedAt, r)
+	return nil
+}
+
+// findFile переводит путь из URL в ключ хранилища и находит файл в базе,
+// доступный текущему пользователю, по токену ?t= или по подписанной
+// ссылке (?exp=&kid=&sig=). Неверная или просроченная подпись — ошибка,
+// даже если файл доступен и без нее.
+func findFile(imageService *service.ImageService, c echo.Context) (*models.StoredFile, error) {
+	unescaped, err := url.PathUnescape(c.Param("*"))
+	if err != nil {
+		return nil, service.ErrImageNotFound
+	}
+
+	// CleanKey отклоняет ".." и служебные пути вида .tus/...
+	key, ok := storage.CleanKey(path.Clean("/" + unescaped))
+	if !ok {
+		return nil, service.ErrImageNotFound
+	}
+
+	access := service.FileAccess{
+		Viewer: middleware.GetCurrentUser(c),
+		Token:  c.QueryParam("t"),
+	}
+	if query := c.QueryParams(); query.Has("sig") {
+		variant, err := imageService.VerifySignature("/images/"+key, query)
+		if err != nil {
+			return nil, err
+		}
+		access.Signed = true
+		access.SignedVariant = variant
+	}
+
+	return imageService.FindFile(key, access)
+}
+
+// fileCacheControl не дает общим кэшам сохранять непубличные файлы: ответ
+// зависит от пользователя или токена, а доступ может быть отозван
+func fileCacheControl(file *models.StoredFile, public string) string {
+	if file.Visibility != models.VisibilityPublic || file.Hidden {
+		return "private, no-cache"
+	}
+	return public
+}
+
+// fileETag — сильный ETag из хеша содержимого. Копия однозначно определяется
+// хешем оригинала и именем варианта. У изображений, загруженных до появления
+// хешей, файл под ключом тоже никогда не меняется.
+func fileETag(file *models.StoredFile) string {
+	tag := file.SHA256
+	if tag == "" {
+		tag = file.Key + "-" + strconv.FormatInt(file.Size, 10)
+	}
+	if file.Variant != "" {
+		tag += "-" + file.Variant
+	}
+	return strconv.Quote(tag)
+}
+
+// contentDisposition формирует заголовок с исходным именем файла;
+// для копии к имени добавляется вариант и расширение копии
+func contentDisposition(disposition string, file *models.StoredFile) string {
+	name := file.OriginalName
+	if file.Variant != "" {
+		base := name[:len(name)-len(path.Ext(name))]
+		name = base + "_" + file.Variant + path.Ext(file.Key)
+	}
+	if name == "" {
+		name = path.Base(file.Key)
+	}
+	// FormatMediaType кодирует не-ASCII имена по RFC 2231
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": name}); value != "" {
+		return value
+	}
+	return disposition
+}
+
+func serveError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrImageNotFound):
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Image not found",
+			Code:  "NOT_FOUND",
+		})
+	case errors.Is(err, service.ErrInvalidSignature):
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_SIGNATURE",
+		})
+	case errors.Is(err, service.ErrSignatureExpired):
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "URL_EXPIRED",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error: "Failed to read image",
+		Code:  "READ_ERROR",
+	})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"errors"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ShareHandler — ссылки /s/<slug>: управление владельцем и открытие по ссылке
+type ShareHandler struct {
+	shareService *service.ShareService
+	serve        *ServeHandler
+}
+
+func NewShareHandler(shareService *service.ShareService, serve *ServeHandler) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		serve:        serve,
+	}
+}
+
+func (h *ShareHandler) CreateShareLink(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	var req models.CreateShareLinkRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	link, err := h.shareService.Create(user.ID, req)
+	if errors.Is(err, service.ErrImageNotFound) {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Image not found",
+			Code:  "NOT_FOUND",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, link)
+}
+
+func (h *ShareHandler) ListShareLinks(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	links, err := h.shareService.ListByUser(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get share links",
+			Code:  "GET_ERROR",
+		})
+	}
+
+	if links == nil {
+		links = []*models.ShareLink{}
+	}
+	return c.JSON(http.StatusOK, links)
+}
+
+func (h *ShareHandler) RevokeShareLink(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	return revokeShareLink(c, h.shareService.Revoke(c.Param("id"), user.ID))
+}
+
+// Open открывает ссылку: GET для ссылок без пароля, POST с {"password"}
+// для любых. Каждое успешное открытие засчитывается как просмотр.
+func (h *ShareHandler) Open(c echo.Context) error {
+	var req models.OpenShareRequest
+	if c.Request().Method == http.MethodPost {
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "Invalid request",
+				Code:  "INVALID_REQUEST",
+			})
+		}
+	}
+
+	view, err := h.shareService.Open(c.Param("slug"), req.Password)
+	if err != nil {
+		return shareError(c, err)
+	}
+
+	// Содержимое зависит от просмотра и токена, кэшировать его нельзя
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, view)
+}
+
+// ServeFile отдает файл изображения ссылки по токену просмотра ?vt=
+func (h *ShareHandler) ServeFile(c echo.Context) error {
+	file, err := h.shareService.FindFile(c.Param("slug"), c.Param("id"), c.Param("variant"), c.QueryParam("vt"))
+	if err != nil {
+		return shareError(c, err)
+	}
+
+	return h.serve.serveFile(c, file, "private, no-cache")
+}
+
+func revokeShareLink(c echo.Context, err error) error {
+	if errors.Is(err, service.ErrShareNotFound) {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Share link not found",
+			Code:  "NOT_FOUND",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to revoke share link",
+			Code:  "REVOKE_ERROR",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func shareError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, service.ErrShareNotFound):
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Share link not found or no longer available",
+			Code:  "NOT_FOUND",
+		})
+	case errors.Is(err, service.ErrSharePasswordRequired):
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "PASSWORD_REQUIRED",
+		})
+	case errors.Is(err, service.ErrShareInvalidPassword):
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_PASSWORD",
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to open share link",
+			Code:  "SHARE_ERROR",
+		})
+	}
+}
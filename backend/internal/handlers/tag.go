@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TagHandler — теги изображений текущего пользователя
+type TagHandler struct {
+	imageService *service.ImageService
+}
+
+func NewTagHandler(imageService *service.ImageService) *TagHandler {
+	return &TagHandler{
+		imageService: imageService,
+	}
+}
+
+// ListTags возвращает все теги пользователя с числом изображений
+func (h *TagHandler) ListTags(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	tags, err := h.imageService.TagCounts(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get tags",
+			Code:  "GET_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// Autocomplete подсказывает теги пользователя по началу ?q=
+func (h *TagHandler) Autocomplete(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	limit := 10
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: "limit must be a positive number",
+				Code:  "VALIDATION_ERROR",
+			})
+		}
+	}
+
+	tags, err := h.imageService.SuggestTags(user.ID, c.QueryParam("q"), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get tags",
+			Code:  "GET_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type TokenHandler struct {
+	authService *service.AuthService
+}
+
+func NewTokenHandler(authService *service.AuthService) *TokenHandler {
+	return &TokenHandler{
+		authService: authService,
+	}
+}
+
+func (h *TokenHandler) CreateToken(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	var req models.CreateTokenRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Invalid request",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	plain, token, err := h.authService.CreateToken(user.ID, req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	}
+
+	return c.JSON(http.StatusCreated, models.CreateTokenResponse{
+		Token:    plain,
+		APIToken: *token,
+	})
+}
+
+func (h *TokenHandler) ListTokens(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	tokens, err := h.authService.ListTokens(user.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to get tokens",
+			Code:  "GET_ERROR",
+		})
+	}
+
+	if tokens == nil {
+		tokens = []*models.APIToken{}
+	}
+	return c.JSON(http.StatusOK, tokens)
+}
+
+func (h *TokenHandler) RevokeToken(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	err := h.authService.RevokeToken(user.ID, c.Param("id"))
+	if errors.Is(err, service.ErrTokenNotFound) {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Token not found",
+			Code:  "NOT_FOUND",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error: "Failed to revoke token",
+			Code:  "REVOKE_ERROR",
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// transformParams — параметры запроса, включающие преобразование
+var transformParams = []string{"w", "h", "fit", "g", "q", "fmt"}
+
+type TransformHandler struct {
+	transformService *service.TransformService
+	imageService     *service.ImageService
+	cacheControl     string // Для публичных изображений, как у ServeHandler
+}
+
+func NewTransformHandler(transformService *service.TransformService, imageService *service.ImageService, cacheControl string) *TransformHandler {
+	return &TransformHandler{
+		transformService: transformService,
+		imageService:     imageService,
+		cacheControl:     cacheControl,
+	}
+}
+
+// Middleware стоит перед ServeHandler на /images/*: запросы с
+// параметрами преобразования обрабатываются здесь, остальные идут дальше
+func (h *TransformHandler) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		query := c.QueryParams()
+		if !hasTransformParams(query) {
+			return next(c)
+		}
+
+		opts, err := parseTransformOptions(query)
+		if err == nil {
+			err = h.transformService.Validate(&opts)
+		}
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error: err.Error(),
+				Code:  "INVALID_TRANSFORM",
+			})
+		}
+
+		// Преобразуются только файлы, известные базе; кэш не знает об удалении
+		// оригинала, поэтому наличие проверяется при каждом запросе
+		file, err := findFile(h.imageService, c)
+		if err != nil {
+			return serveError(c, err)
+		}
+
+		result, err := h.transformService.Transform(file.Key, file.MimeType, opts)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Error: "Failed to transform image",
+				Code:  "TRANSFORM_ERROR",
+			})
+		}
+		defer result.Close()
+
+		// Кэшируется так же, как исходный файл: видимость изображения может измениться
+		c.Response().Header().Set(echo.HeaderContentType, result.MimeType)
+		c.Response().Header().Set("Cache-Control", fileCacheControl(file, h.cacheControl))
+		c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(c.Response(), c.Request(), "", result.ModTime, result.Content)
+		return nil
+	}
+}
+
+func hasTransformParams(query url.Values) bool {
+	for _, name := range transformParams {
+		if query.Has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTransformOptions(query url.Values) (service.TransformOptions, error) {
+	opts := service.TransformOptions{
+		Fit:     query.Get("fit"),
+		Gravity: query.Get("g"),
+		Format:  query.Get("fmt"),
+	}
+
+	for name, target := range map[string]*int{"w": &opts.Width, "h": &opts.Height, "q": &opts.Quality} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return opts, errors.New("invalid value for " + name)
+		}
+		*target = n
+	}
+
+	return opts, nil
+}
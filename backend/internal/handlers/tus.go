@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"errors"
+	"image-uploader-backend/internal/middleware"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	tusVersion    = "1.0.0"
+	tusExtensions = "creation,expiration,termination,checksum"
+
+	// statusChecksumMismatch — код ответа расширения checksum
+	statusChecksumMismatch = 460
+)
+
+// TusExposedHeaders — заголовки ответов tus, которые должны быть видны
+// браузерному клиенту через CORS
+var TusExposedHeaders = []string{
+	echo.HeaderLocation, "Upload-Offset", "Upload-Length", "Upload-Expires",
+	"Tus-Resumable", "Tus-Version", "Tus-Extension", "Tus-Max-Size", "Tus-Checksum-Algorithm",
+	"X-Image-Id",
+}
+
+// TusHandler реализует протокол tus 1.0: ядро и расширения creation,
+// expiration, termination и checksum
+type TusHandler struct {
+	tusService *service.TusService
+	maxSize    int64
+}
+
+func NewTusHandler(tusService *service.TusService, maxSize int64) *TusHandler {
+	return &TusHandler{
+		tusService: tusService,
+		maxSize:    maxSize,
+	}
+}
+
+// Middleware добавляет Tus-Resumable ко всем ответам и отклоняет запросы
+// с неподдерживаемой версией протокола (кроме OPTIONS)
+func (h *TusHandler) Middleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set("Tus-Resumable", tusVersion)
+
+		if c.Request().Method != http.MethodOptions && c.Request().Header.Get("Tus-Resumable") != tusVersion {
+			c.Response().Header().Set("Tus-Version", tusVersion)
+			return c.JSON(http.StatusPreconditionFailed, models.ErrorResponse{
+				Error: "Unsupported tus version",
+				Code:  "UNSUPPORTED_VERSION",
+			})
+		}
+
+		return next(c)
+	}
+}
+
+// Options сообщает возможности сервера
+func (h *TusHandler) Options(c echo.Context) error {
+	header := c.Response().Header()
+	header.Set("Tus-Version", tusVersion)
+	header.Set("Tus-Extension", tusExtensions)
+	header.Set("Tus-Max-Size", strconv.FormatInt(h.maxSize, 10))
+	header.Set("Tus-Checksum-Algorithm", strings.Join(service.TusChecksumAlgorithms(), ","))
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Create создает загрузку (расширение creation). Upload-Defer-Length не поддерживается.
+func (h *TusHandler) Create(c echo.Context) error {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Unauthorized",
+			Code:  "UNAUTHORIZED",
+		})
+	}
+
+	length, err := strconv.ParseInt(c.Request().Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Upload-Length header is required",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	metadata, err := parseUploadMetadata(c.Request().Header.Get("Upload-Metadata"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	upload, err := h.tusService.Create(user.ID, length, metadata["filename"], metadata["filetype"],
+		metadata["visibility"], metadata["album"], service.ParseTags(metadata["tags"]), metadata["caption"])
+	if err != nil {
+		return tusError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderLocation, strings.TrimSuffix(c.Request().URL.Path, "/")+"/"+upload.ID)
+	c.Response().Header().Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	return c.NoContent(http.StatusCreated)
+}
+
+// Head возвращает текущее смещение загрузки
+func (h *TusHandler) Head(c echo.Context) error {
+	upload, err := h.loadUpload(c)
+	if err != nil {
+		// У ответа на HEAD нет тела
+		status, _ := tusErrorStatus(err)
+		return c.NoContent(status)
+	}
+
+	header := c.Response().Header()
+	header.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	header.Set("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	header.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	header.Set("Cache-Control", "no-store")
+	if upload.ImageID != "" {
+		header.Set("X-Image-Id", upload.ImageID)
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// Patch дописывает часть данных. Когда получен последний байт, файл
+// сохраняется как изображение, и его id возвращается в X-Image-Id.
+func (h *TusHandler) Patch(c echo.Context) error {
+	upload, err := h.loadUpload(c)
+	if err != nil {
+		return tusError(c, err)
+	}
+
+	if c.Request().Header.Get(echo.HeaderContentType) != "application/offset+octet-stream" {
+		return c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+			Error: "Content-Type must be application/offset+octet-stream",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	offset, err := strconv.ParseInt(c.Request().Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: "Upload-Offset header is required",
+			Code:  "INVALID_REQUEST",
+		})
+	}
+
+	upload, image, err := h.tusService.WriteChunk(upload, offset, c.Request().Body, c.Request().Header.Get("Upload-Checksum"))
+	if err != nil {
+		return tusError(c, err)
+	}
+
+	header := c.Response().Header()
+	header.Set("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	header.Set("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	if image != nil {
+		header.Set("X-Image-Id", image.ID)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Delete прерывает загрузку (расширение termination)
+func (h *TusHandler) Delete(c echo.Context) error {
+	upload, err := h.loadUpload(c)
+	if err != nil {
+		return tusError(c, err)
+	}
+
+	if err := h.tusService.Terminate(upload); err != nil {
+		return tusError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *TusHandler) loadUpload(c echo.Context) (*models.TusUpload, error) {
+	user := middleware.GetCurrentUser(c)
+	if user == nil {
+		return nil, service.ErrTusUploadNotFound
+	}
+	return h.tusService.Get(c.Param("id"), user.ID)
+}
+
+// parseUploadMetadata разбирает Upload-Metadata: пары "ключ base64(значение)"
+// через запятую; значение может отсутствовать
+func parseUploadMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if header == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("invalid Upload-Metadata header")
+		}
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("invalid Upload-Metadata value for " + key)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// tusErrorStatus сопоставляет ошибку сервиса с кодом ответа и кодом ошибки
+func tusErrorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, service.ErrTusUploadNotFound), errors.Is(err, service.ErrAlbumNotFound):
+		return http.StatusNotFound, "NOT_FOUND"
+	case errors.Is(err, service.ErrTusInvalidLength), errors.Is(err, service.ErrInvalidVisibility),
+		errors.Is(err, service.ErrInvalidTags), errors.Is(err, service.ErrInvalidCaption):
+		return http.StatusBadRequest, "INVALID_REQUEST"
+	case errors.Is(err, service.ErrTusTooLarge), errors.Is(err, service.ErrTusExceedsLength):
+		return http.StatusRequestEntityTooLarge, "UPLOAD_TOO_LARGE"
+	case errors.Is(err, service.ErrQuotaExceeded):
+		return http.StatusForbidden, "QUOTA_EXCEEDED"
+	case errors.Is(err, service.ErrTusOffsetMismatch):
+		return http.StatusConflict, "OFFSET_MISMATCH"
+	case errors.Is(err, service.ErrTusInvalidChecksum):
+		return http.StatusBadRequest, "INVALID_CHECKSUM"
+	case errors.Is(err, service.ErrTusUnsupportedChecksum):
+		return http.StatusBadRequest, "UNSUPPORTED_CHECKSUM"
+	case errors.Is(err, service.ErrTusChecksumMismatch):
+		return statusChecksumMismatch, "CHECKSUM_MISMATCH"
+	case errors.Is(err, service.ErrTusLocked):
+		return http.StatusLocked, "UPLOAD_LOCKED"
+	case errors.Is(err, service.ErrTusInvalidImage):
+		return http.StatusUnprocessableEntity, "VALIDATION_ERROR"
+	default:
+		return http.StatusInternalServerError, "UPLOAD_ERROR"
+	}
+}
+
+func tusError(c echo.Context, err error) error {
+	status, code := tusErrorStatus(err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		message = "Failed to process upload"
+	}
+	return c.JSON(status, models.ErrorResponse{
+		Error: message,
+		Code:  code,
+	})
+}
@@ -1,21 +1,32 @@
 package handlers
 
 import (
+	"errors"
 	"image-uploader-backend/internal/middleware"
 	"image-uploader-backend/internal/models"
 	"image-uploader-backend/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 )
 
 type UploadHandler struct {
 	imageService *service.ImageService
+	limits       BatchLimits
 }
 
-func NewUploadHandler(imageService *service.ImageService) *UploadHandler {
+// BatchLimits — ограничения пакетной загрузки на один запрос
+type BatchLimits struct {
+	MaxFiles    int
+	MaxBytes    int64 // Суммарный размер файлов
+	MaxFileSize int64
+}
+
+func NewUploadHandler(imageService *service.ImageService, limits BatchLimits) *UploadHandler {
 	return &UploadHandler{
 		imageService: imageService,
+		limits:       limits,
 	}
 }
 
@@ -39,15 +50,46 @@ func (h *UploadHandler) UploadImage(c echo.Context) error {
 	}
 
 	// Валидируем файл
-	if err := h.imageService.ValidateFile(file); err != nil {
+	info, err := h.imageService.ValidateFile(file)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Error: err.Error(),
 			Code:  "VALIDATION_ERROR",
 		})
 	}
 
+	// dedupe=true возвращает уже загруженное пользователем изображение
+	// с тем же содержимым вместо создания новой записи; album добавляет
+	// изображение в конец альбома пользователя, tags — теги через запятую
+	opts := service.SaveOptions{
+		Visibility: c.FormValue("visibility"),
+		AlbumID:    c.FormValue("album"),
+		Caption:    c.FormValue("caption"),
+		Tags:       service.ParseTags(c.FormValue("tags")),
+	}
+	opts.Dedupe, _ = strconv.ParseBool(c.FormValue("dedupe"))
+
 	// Сохраняем файл с привязкой к пользователю
-	image, err := h.imageService.SaveFile(file, user.ID)
+	image, duplicate, err := h.imageService.SaveFile(file, info, user.ID, opts)
+	if errors.Is(err, service.ErrInvalidVisibility) || errors.Is(err, service.ErrInvalidTags) ||
+		errors.Is(err, service.ErrInvalidCaption) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error: err.Error(),
+			Code:  "VALIDATION_ERROR",
+		})
+	}
+	if errors.Is(err, service.ErrAlbumNotFound) {
+		return c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Error: "Album not found",
+			Code:  "NOT_FOUND",
+		})
+	}
+	if errors.Is(err, service.ErrQuotaExceeded) {
+		return c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error: "Storage quota exceeded",
+			Code:  "QUOTA_EXCEEDED",
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Error: "Failed to save image",
@@ -56,9 +98,22 @@ func (h *UploadHandler) UploadImage(c echo.Context) error {
 	}
 
 	// Возвращаем ответ
-	return c.JSON(http.StatusOK, models.UploadResponse{
-		URL:      image.URL,
-		ID:       image.ID,
-		Filename: image.FileName,
-	})
+	return c.JSON(http.StatusOK, uploadResponse(image, duplicate))
+}
+
+func uploadResponse(image *models.Image, duplicate bool) *models.UploadResponse {
+	return &models.UploadResponse{
+		URL:        image.URL,
+		ID:         image.ID,
+		Filename:   image.FileName,
+		SHA256:     image.SHA256,
+		Visibility: image.Visibility,
+		Width:      image.Width,
+		Height:     image.Height,
+		Variants:   image.Variants,
+		Metadata:   image.Metadata,
+		Tags:       image.Tags,
+
+		Duplicate: duplicate,
+	}
 }
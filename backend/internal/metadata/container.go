@@ -0,0 +1,241 @@
+// Package metadata извлекает EXIF из JPEG, PNG и WebP и вырезает
+// метаданные из файла без перекодирования изображения. Файл читается
+// через io.ReaderAt: в память загружаются только заголовки блоков и EXIF.
+package metadata
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Виды блоков метаданных внутри контейнера
+const (
+	kindEXIF = "exif"
+	kindXMP  = "xmp"
+	kindText = "text" // Комментарии JPEG, IPTC, текстовые чанки PNG
+)
+
+// maxEXIFSize — больший EXIF не разбирается. В JPEG сегмент не длиннее
+// 64 КБ, в PNG и WebP размер чанка ничем не ограничен.
+const maxEXIFSize = 1 << 20
+
+// Сигнатуры сегментов и чанков с метаданными
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	jpegXMPHeader  = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	pngXMPKeyword  = []byte("XML:com.adobe.xmp\x00")
+)
+
+// block — блок метаданных в файле: [start, end) целиком и
+// [payloadStart, end) — полезная нагрузка
+type block struct {
+	kind         string
+	start        int64
+	payloadStart int64
+	payloadEnd   int64
+	end          int64
+}
+
+// blocks находит блоки метаданных в файле указанного формата
+func blocks(r io.ReaderAt, size int64, format string) []block {
+	var result []block
+
+	switch format {
+	case "jpeg":
+		walkJPEG(r, size, func(marker byte, start, end int64) {
+			body := start + 4
+			prefix := readPrefix(r, body, end, len(jpegXMPHeader))
+			switch {
+			case marker == 0xE1 && bytes.HasPrefix(prefix, jpegExifHeader):
+				result = append(result, block{kindEXIF, start, body + int64(len(jpegExifHeader)), end, end})
+			case marker == 0xE1 && bytes.HasPrefix(prefix, jpegXMPHeader):
+				result = append(result, block{kindXMP, start, body, end, end})
+			case marker == 0xED || marker == 0xFE: // APP13 (IPTC) и комментарий
+				result = append(result, block{kindText, start, body, end, end})
+			}
+		})
+
+	case "png":
+		walkPNG(r, size, func(chunkType string, start, end int64) {
+			body, bodyEnd := start+8, end-4
+			switch {
+			case chunkType == "eXIf":
+				result = append(result, block{kindEXIF, start, body, bodyEnd, end})
+			case chunkType == "iTXt" && bytes.HasPrefix(readPrefix(r, body, bodyEnd, len(pngXMPKeyword)), pngXMPKeyword):
+				result = append(result, block{kindXMP, start, body, bodyEnd, end})
+			case chunkType == "tEXt" || chunkType == "zTXt" || chunkType == "iTXt":
+				result = append(result, block{kindText, start, body, bodyEnd, end})
+			}
+		})
+
+	case "webp":
+		walkWebP(r, size, func(fourCC string, start, end int64) {
+			body := start + 8
+			switch fourCC {
+			case "EXIF":
+				// Некоторые кодировщики оставляют в WebP JPEG-заголовок "Exif\0\0"
+				if bytes.HasPrefix(readPrefix(r, body, end, len(jpegExifHeader)), jpegExifHeader) {
+					body += int64(len(jpegExifHeader))
+				}
+				result = append(result, block{kindEXIF, start, body, end, end})
+			case "XMP ":
+				result = append(result, block{kindXMP, start, body, end, end})
+			}
+		})
+	}
+
+	return result
+}
+
+// readPrefix читает до n первых байтов диапазона [start, end)
+func readPrefix(r io.ReaderAt, start, end int64, n int) []byte {
+	buf := make([]byte, min(int64(n), max(end-start, 0)))
+	read, _ := r.ReadAt(buf, start)
+	return buf[:read]
+}
+
+// exifBlock возвращает блок EXIF или false, если его нет
+func exifBlock(r io.ReaderAt, size int64, format string) (block, bool) {
+	for _, b := range blocks(r, size, format) {
+		if b.kind == kindEXIF {
+			return b, true
+		}
+	}
+	return block{}, false
+}
+
+// readPayload читает TIFF-структуру EXIF. Слишком большой или обрезанный
+// EXIF дает nil.
+func readPayload(r io.ReaderAt, b block) []byte {
+	n := b.payloadEnd - b.payloadStart
+	if n <= 0 || n > maxEXIFSize {
+		return nil
+	}
+	payload := make([]byte, n)
+	if _, err := r.ReadAt(payload, b.payloadStart); err != nil {
+		return nil
+	}
+	return payload
+}
+
+// edit заменяет диапазон [start, end) исходного файла на data
+type edit struct {
+	start, end int64
+	data       []byte
+}
+
+// writeEdited пишет в w файл с непересекающимися правками, отсортированными
+// по началу, и возвращает число записанных байтов
+func writeEdited(w io.Writer, r io.ReaderAt, size int64, edits []edit) (int64, error) {
+	var written, pos int64
+	for _, e := range append(edits, edit{start: size, end: size}) {
+		n, err := io.Copy(w, io.NewSectionReader(r, pos, e.start-pos))
+		written += n
+		if err != nil {
+			return written, err
+		}
+		m, err := w.Write(e.data)
+		written += int64(m)
+		if err != nil {
+			return written, err
+		}
+		pos = e.end
+	}
+	return written, nil
+}
+
+// webpHeaderEdits пересчитывает размер RIFF под итоговый размер файла и
+// снимает в VP8X флаги удаленных чанков: EXIF (0x08) и XMP (0x04)
+func webpHeaderEdits(r io.ReaderAt, size, outSize int64, exifRemoved, xmpRemoved bool) []edit {
+	riffSize := make([]byte, 4)
+	binary.LittleEndian.PutUint32(riffSize, uint32(outSize-8))
+	edits := []edit{{4, 8, riffSize}}
+
+	walkWebP(r, size, func(fourCC string, start, end int64) {
+		if fourCC != "VP8X" || end-start <= 8 {
+			return
+		}
+		flags := readPrefix(r, start+8, end, 1)
+		if len(flags) == 0 {
+			return
+		}
+		if exifRemoved {
+			flags[0] &^= 0x08
+		}
+		if xmpRemoved {
+			flags[0] &^= 0x04
+		}
+		edits = append(edits, edit{start + 8, start + 9, flags})
+	})
+	return edits
+}
+
+// walkJPEG перебирает сегменты заголовка JPEG до начала данных скана.
+// start указывает на маркер 0xFF, end — на конец сегмента.
+func walkJPEG(r io.ReaderAt, size int64, fn func(marker byte, start, end int64)) {
+	header := make([]byte, 4)
+	pos := int64(2)
+	for pos+4 <= size {
+		if _, err := r.ReadAt(header, pos); err != nil {
+			return
+		}
+		if header[0] != 0xFF {
+			return
+		}
+		marker := header[1]
+		// Маркеры без длины
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			pos += 2
+			continue
+		}
+		// Начало скана или конец файла — дальше метаданных нет
+		if marker == 0xDA || marker == 0xD9 {
+			return
+		}
+
+		length := int64(binary.BigEndian.Uint16(header[2:4]))
+		end := pos + 2 + length
+		if length < 2 || end > size {
+			return
+		}
+		fn(marker, pos, end)
+		pos = end
+	}
+}
+
+// walkPNG перебирает чанки PNG. start указывает на поле длины, end — на конец CRC.
+func walkPNG(r io.ReaderAt, size int64, fn func(chunkType string, start, end int64)) {
+	header := make([]byte, 8)
+	pos := int64(8)
+	for pos+12 <= size {
+		if _, err := r.ReadAt(header, pos); err != nil {
+			return
+		}
+		length := int64(binary.BigEndian.Uint32(header[0:4]))
+		end := pos + 12 + length
+		if end > size {
+			return
+		}
+		fn(string(header[4:8]), pos, end)
+		pos = end
+	}
+}
+
+// walkWebP перебирает чанки RIFF контейнера WebP. end включает байт выравнивания.
+func walkWebP(r io.ReaderAt, size int64, fn func(fourCC string, start, end int64)) {
+	header := make([]byte, 8)
+	pos := int64(12)
+	for pos+8 <= size {
+		if _, err := r.ReadAt(header, pos); err != nil {
+			return
+		}
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+		end := pos + 8 + chunkSize + chunkSize%2
+		if end > size {
+			end = size
+		}
+		fn(string(header[0:4]), pos, end)
+		pos = end
+	}
+}
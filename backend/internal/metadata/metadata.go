@@ -0,0 +1,228 @@
+package metadata
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"image"
+	"image-uploader-backend/internal/models"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// gpsInfoTag — тег IFD0 со смещением GPS IFD
+const gpsInfoTag = 0x8825
+
+// Размеры значений TIFF по коду типа
+var tiffTypeSizes = map[uint16]uint32{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// Extract читает EXIF из файла размером size. Если EXIF нет, он поврежден
+// или слишком велик, возвращаются метаданные с ориентацией по умолчанию.
+func Extract(r io.ReaderAt, size int64, format string) *models.ImageMetadata {
+	meta := &models.ImageMetadata{Orientation: 1, Stripped: models.StripNone}
+
+	b, ok := exifBlock(r, size, format)
+	if !ok {
+		return meta
+	}
+	payload := readPayload(r, b)
+	if payload == nil {
+		return meta
+	}
+
+	// goexif может вернуть частично разобранные данные вместе с ошибкой,
+	// поэтому ориентируемся только на наличие результата
+	x, _ := exif.Decode(bytes.NewReader(payload))
+	if x == nil {
+		return meta
+	}
+
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if value, err := tag.Int(0); err == nil && value >= 1 && value <= 8 {
+			meta.Orientation = value
+		}
+	}
+	if takenAt, err := x.DateTime(); err == nil {
+		meta.TakenAt = &takenAt
+	}
+	meta.CameraMake = stringTag(x, exif.Make)
+	meta.CameraModel = stringTag(x, exif.Model)
+	meta.LensModel = stringTag(x, exif.LensModel)
+	if lat, long, err := x.LatLong(); err == nil {
+		meta.GPSLatitude = &lat
+		meta.GPSLongitude = &long
+	}
+
+	return meta
+}
+
+func stringTag(x *exif.Exif, name exif.FieldName) string {
+	tag, err := x.Get(name)
+	if err != nil {
+		return ""
+	}
+	value, err := tag.StringVal()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(value, "\x00"))
+}
+
+// Strip пишет в w копию файла размером size без метаданных согласно
+// режиму и возвращает ее размер: StripGPS обнуляет GPS IFD в EXIF и
+// удаляет XMP (в нем тоже бывают координаты), StripAll удаляет EXIF, XMP
+// и текстовые блоки целиком. Пиксельные данные не перекодируются.
+// EXIF, который слишком велик для разбора, при StripGPS удаляется целиком.
+func Strip(w io.Writer, r io.ReaderAt, size int64, format, mode string) (int64, error) {
+	drop := make(map[string]bool)
+	switch mode {
+	case models.StripAll:
+		drop[kindEXIF], drop[kindXMP], drop[kindText] = true, true, true
+	case models.StripGPS:
+		drop[kindXMP] = true
+	default:
+		return io.Copy(w, io.NewSectionReader(r, 0, size))
+	}
+
+	var edits []edit
+	removed := int64(0)
+	for _, b := range blocks(r, size, format) {
+		if b.kind == kindEXIF && !drop[kindEXIF] {
+			if payload := readPayload(r, b); payload != nil {
+				clearGPS(payload)
+				edits = append(edits, edit{b.payloadStart, b.payloadEnd, payload})
+				continue
+			}
+			drop[kindEXIF] = true
+		}
+		if drop[b.kind] {
+			edits = append(edits, edit{b.start, b.end, nil})
+			removed += b.end - b.start
+		}
+	}
+
+	if format == "webp" && size >= 12 {
+		edits = append(edits, webpHeaderEdits(r, size, size-removed, drop[kindEXIF], drop[kindXMP])...)
+	}
+	slices.SortFunc(edits, func(a, b edit) int { return cmp.Compare(a.start, b.start) })
+	return writeEdited(w, r, size, edits)
+}
+
+// clearGPS обнуляет записи GPS IFD и их значения прямо в TIFF-структуре.
+// Смещения остальных тегов не меняются, поэтому EXIF остается валидным.
+func clearGPS(tiff []byte) {
+	if len(tiff) < 8 {
+		return
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return
+	}
+
+	ifd0 := order.Uint32(tiff[4:8])
+	entry, ok := findEntry(tiff, order, ifd0, gpsInfoTag)
+	if !ok {
+		return
+	}
+	gpsIFD := uint64(order.Uint32(tiff[entry+8 : entry+12]))
+	if gpsIFD+2 > uint64(len(tiff)) {
+		return
+	}
+
+	count := uint64(order.Uint16(tiff[gpsIFD : gpsIFD+2]))
+	entries := gpsIFD + 2
+	if entries+count*12 > uint64(len(tiff)) {
+		return
+	}
+
+	for i := uint64(0); i < count; i++ {
+		e := tiff[entries+i*12 : entries+i*12+12]
+		size := uint64(tiffTypeSizes[order.Uint16(e[2:4])]) * uint64(order.Uint32(e[4:8]))
+		// Значения длиннее 4 байт хранятся вне записи по смещению
+		if size > 4 {
+			offset := uint64(order.Uint32(e[8:12]))
+			if offset+size <= uint64(len(tiff)) {
+				clear(tiff[offset : offset+size])
+			}
+		}
+		clear(e)
+	}
+
+	// Пустой IFD: ноль записей, а смещение следующего IFD попадает
+	// на уже обнуленную первую запись
+	order.PutUint16(tiff[gpsIFD:gpsIFD+2], 0)
+}
+
+// findEntry возвращает смещение записи IFD с указанным тегом
+func findEntry(tiff []byte, order binary.ByteOrder, ifd uint32, tag uint16) (uint64, bool) {
+	offset := uint64(ifd)
+	if offset+2 > uint64(len(tiff)) {
+		return 0, false
+	}
+
+	count := uint64(order.Uint16(tiff[offset : offset+2]))
+	for i := uint64(0); i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > uint64(len(tiff)) {
+			return 0, false
+		}
+		if order.Uint16(tiff[entry:entry+2]) == tag {
+			return entry, true
+		}
+	}
+	return 0, false
+}
+
+// Orient поворачивает и отражает изображение согласно тегу EXIF Orientation,
+// чтобы оно отображалось правильно без учета метаданных
+func Orient(src image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// Для 5–8 ширина и высота меняются местами
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Отражение по горизонтали
+				dx, dy = w-1-x, y
+			case 3: // Поворот на 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // Отражение по вертикали
+				dx, dy = x, h-1-y
+			case 5: // Транспонирование
+				dx, dy = y, x
+			case 6: // Поворот на 90° по часовой
+				dx, dy = h-1-y, x
+			case 7: // Транспонирование с поворотом на 180°
+				dx, dy = h-1-y, w-1-x
+			case 8: // Поворот на 90° против часовой
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, src.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+
+	return dst
+}
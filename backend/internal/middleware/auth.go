@@ -1,19 +1,28 @@
 package middleware
 
 import (
+	"errors"
 	"image-uploader-backend/internal/models"
 	"image-uploader-backend/internal/service"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
 
 const (
-	UserContextKey = "user"
+	UserContextKey  = "user"
+	TokenContextKey = "api_token"
 )
 
-// validateSession проверяет сессию и возвращает пользователя или ошибку
-func validateSession(c echo.Context, authService *service.AuthService) (*models.User, error) {
+// validateSession проверяет сессию или Bearer токен и возвращает пользователя или ошибку.
+// Токен принимается только если у него есть все перечисленные scopes,
+// маршруты без scopes доступны только по сессии.
+func validateSession(c echo.Context, authService *service.AuthService, scopes []string) (*models.User, error) {
+	if header := c.Request().Header.Get("Authorization"); header != "" {
+		return validateToken(c, authService, header, scopes)
+	}
+
 	cookie, err := c.Cookie("session_id")
 	if err != nil || cookie.Value == "" {
 		return nil, echo.NewHTTPError(http.StatusUnauthorized, models.ErrorResponse{
@@ -23,6 +32,12 @@ func validateSession(c echo.Context, authService *service.AuthService) (*models.
 	}
 
 	user, err := authService.ValidateSession(cookie.Value)
+	if errors.Is(err, service.ErrAccountDisabled) {
+		return nil, accountDisabled()
+	}
+	if errors.Is(err, service.ErrPasswordResetRequired) {
+		return nil, passwordResetRequired()
+	}
 	if err != nil {
 		return nil, echo.NewHTTPError(http.StatusUnauthorized, models.ErrorResponse{
 			Error: "Invalid or expired session",
@@ -33,10 +48,73 @@ func validateSession(c echo.Context, authService *service.AuthService) (*models.
 	return user, nil
 }
 
-func RequireAuth(authService *service.AuthService) echo.MiddlewareFunc {
+// validateToken проверяет заголовок Authorization: Bearer <token>
+func validateToken(c echo.Context, authService *service.AuthService, header string, scopes []string) (*models.User, error) {
+	scheme, plain, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(plain) == "" {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Invalid authorization header",
+			Code:  "INVALID_TOKEN",
+		})
+	}
+
+	user, token, err := authService.ValidateToken(strings.TrimSpace(plain))
+	if errors.Is(err, service.ErrAccountDisabled) {
+		return nil, accountDisabled()
+	}
+	if errors.Is(err, service.ErrPasswordResetRequired) {
+		return nil, passwordResetRequired()
+	}
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusUnauthorized, models.ErrorResponse{
+			Error: "Invalid, expired or revoked token",
+			Code:  "INVALID_TOKEN",
+		})
+	}
+
+	if len(scopes) == 0 {
+		return nil, echo.NewHTTPError(http.StatusForbidden, models.ErrorResponse{
+			Error: "Forbidden - API tokens are not accepted for this endpoint",
+			Code:  "FORBIDDEN",
+		})
+	}
+
+	for _, scope := range scopes {
+		if !token.HasScope(scope) {
+			return nil, echo.NewHTTPError(http.StatusForbidden, models.ErrorResponse{
+				Error: "Forbidden - token is missing scope " + scope,
+				Code:  "INSUFFICIENT_SCOPE",
+			})
+		}
+	}
+
+	c.Set(TokenContextKey, token)
+	return user, nil
+}
+
+// accountDisabled — ответ на запрос от отключенной учетной записи
+func accountDisabled() error {
+	return echo.NewHTTPError(http.StatusForbidden, models.ErrorResponse{
+		Error: "Account is disabled",
+		Code:  "ACCOUNT_DISABLED",
+	})
+}
+
+// passwordResetRequired — ответ на запрос пользователя, которому
+// администратор сбросил пароль
+func passwordResetRequired() error {
+	return echo.NewHTTPError(http.StatusForbidden, models.ErrorResponse{
+		Error: "Password must be changed",
+		Code:  "PASSWORD_RESET_REQUIRED",
+	})
+}
+
+// RequireAuth пропускает любого аутентифицированного пользователя.
+// scopes перечисляют области, с которыми запрос можно выполнить по API токену.
+func RequireAuth(authService *service.AuthService, scopes ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			user, err := validateSession(c, authService)
+			user, err := validateSession(c, authService, scopes)
 			if err != nil {
 				return err
 			}
@@ -47,10 +125,24 @@ func RequireAuth(authService *service.AuthService) echo.MiddlewareFunc {
 	}
 }
 
-func RequireUser(authService *service.AuthService) echo.MiddlewareFunc {
+// OptionalAuth определяет пользователя, если запрос аутентифицирован, и
+// пропускает его дальше в любом случае. Недействительные учетные данные
+// не приводят к ошибке: запрос выполняется как анонимный.
+func OptionalAuth(authService *service.AuthService, scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if user, err := validateSession(c, authService, scopes); err == nil {
+				c.Set(UserContextKey, user)
+			}
+			return next(c)
+		}
+	}
+}
+
+func RequireUser(authService *service.AuthService, scopes ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			user, err := validateSession(c, authService)
+			user, err := validateSession(c, authService, scopes)
 			if err != nil {
 				return err
 			}
@@ -68,10 +160,10 @@ func RequireUser(authService *service.AuthService) echo.MiddlewareFunc {
 	}
 }
 
-func RequireAdmin(authService *service.AuthService) echo.MiddlewareFunc {
+func RequireAdmin(authService *service.AuthService, scopes ...string) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			user, err := validateSession(c, authService)
+			user, err := validateSession(c, authService, scopes)
 			if err != nil {
 				return err
 			}
@@ -97,3 +189,11 @@ func GetCurrentUser(c echo.Context) *models.User {
 	return user
 }
 
+// GetCurrentToken возвращает API токен, если запрос аутентифицирован по нему
+func GetCurrentToken(c echo.Context) *models.APIToken {
+	token, ok := c.Get(TokenContextKey).(*models.APIToken)
+	if !ok {
+		return nil
+	}
+	return token
+}
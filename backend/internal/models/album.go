@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Album — упорядоченный набор изображений пользователя
+type Album struct {
+	ID           string    `json:"id" db:"id"`
+	UserID       string    `json:"user_id" db:"user_id"`
+	Name         string    `json:"name" db:"name"`
+	Description  string    `json:"description" db:"description"`
+	Visibility   string    `json:"visibility" db:"visibility"`
+	AccessToken  string    `json:"-" db:"access_token"`                // Входит в URL unlisted альбома
+	CoverImageID string    `json:"cover_image_id" db:"cover_image_id"` // Пусто — обложка первое изображение
+	ImageCount   int       `json:"image_count" db:"-"`
+	URL          string    `json:"url" db:"-"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+
+	Cover  *Image   `json:"cover" db:"-"`
+	Images []*Image `json:"images,omitempty" db:"-"` // Только при получении одного альбома
+}
+
+type CreateAlbumRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Visibility  string `json:"visibility,omitempty"` // По умолчанию DEFAULT_VISIBILITY
+}
+
+// UpdateAlbumRequest — изменяемые свойства альбома; отсутствующие поля
+// не меняются, пустой cover_image_id возвращает обложку по умолчанию
+type UpdateAlbumRequest struct {
+	Name         *string `json:"name"`
+	Description  *string `json:"description"`
+	Visibility   *string `json:"visibility"`
+	CoverImageID *string `json:"cover_image_id"`
+}
+
+// AlbumImagesRequest — изображения для добавления в альбом или новый порядок
+type AlbumImagesRequest struct {
+	ImageIDs []string `json:"image_ids"`
+}
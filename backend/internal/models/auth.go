@@ -3,7 +3,8 @@ package models
 type RegisterRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=50"`
 	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role,omitempty"` // По умолчанию "user", админ создается вручную
+	// Инвайт-код: обязателен в режиме invite, определяет роль нового пользователя
+	InviteCode string `json:"invite_code,omitempty"`
 }
 
 type LoginRequest struct {
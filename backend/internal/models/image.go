@@ -2,22 +2,134 @@ package models
 
 import "time"
 
+// Видимость изображения
+const (
+	VisibilityPublic   = "public"   // Доступно всем
+	VisibilityUnlisted = "unlisted" // Только по ссылке с токеном доступа
+	VisibilityPrivate  = "private"  // Только владельцу и администраторам
+)
+
+// ValidVisibility проверяет значение видимости
+func ValidVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+		return true
+	}
+	return false
+}
+
 type Image struct {
 	ID           string    `json:"id" db:"id"`
 	UserID       string    `json:"user_id" db:"user_id"`
 	OriginalName string    `json:"original_name" db:"original_name"`
+	Caption      string    `json:"caption" db:"caption"`
 	FileName     string    `json:"file_name" db:"file_name"`
-	FilePath     string    `json:"file_path" db:"file_path"`
+	StorageKey   string    `json:"storage_key" db:"storage_key"`
 	MimeType     string    `json:"mime_type" db:"mime_type"`
 	Size         int64     `json:"size" db:"size"`
+	Width        int       `json:"width" db:"width"`
+	Height       int       `json:"height" db:"height"`
+	SHA256       string    `json:"sha256,omitempty" db:"sha256"`
+	Visibility   string    `json:"visibility" db:"visibility"`
+	AccessToken  string    `json:"-" db:"access_token"` // Входит в URL unlisted изображения
 	URL          string    `json:"url" db:"-"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+
+	// Модерация: скрыто администратором (видно только владельцу
+	// и администраторам) и отмечено для проверки
+	HiddenAt  *time.Time `json:"hidden_at,omitempty" db:"hidden_at"`
+	FlaggedAt *time.Time `json:"flagged_at,omitempty" db:"flagged_at"`
+
+	// URL уменьшенных копий по имени варианта (thumb, medium, ...)
+	Variants map[string]string `json:"variants,omitempty" db:"-"`
+
+	// Метаданные EXIF, извлеченные при загрузке
+	Metadata *ImageMetadata `json:"metadata,omitempty" db:"-"`
+
+	// Теги в нормализованном виде, по алфавиту
+	Tags []string `json:"tags" db:"-"`
+}
+
+type ImageVariant struct {
+	ImageID    string    `json:"image_id" db:"image_id"`
+	Name       string    `json:"name" db:"name"`
+	FileName   string    `json:"file_name" db:"file_name"`
+	StorageKey string    `json:"storage_key" db:"storage_key"`
+	MimeType   string    `json:"mime_type" db:"mime_type"`
+	Width      int       `json:"width" db:"width"`
+	Height     int       `json:"height" db:"height"`
+	Size       int64     `json:"size" db:"size"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 }
 
 type UploadResponse struct {
-	URL      string `json:"url"`
-	ID       string `json:"id"`
-	Filename string `json:"filename"`
+	URL        string            `json:"url"`
+	ID         string            `json:"id"`
+	Filename   string            `json:"filename"`
+	SHA256     string            `json:"sha256"`
+	Visibility string            `json:"visibility"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Variants   map[string]string `json:"variants,omitempty"`
+	Metadata   *ImageMetadata    `json:"metadata,omitempty"`
+	Tags       []string          `json:"tags,omitempty"`
+
+	// Duplicate — вернулось ранее загруженное изображение (запрос с dedupe)
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// UpdateImageRequest — изменяемые свойства изображения. Пустая visibility
+// не меняет видимость, если заданы подпись или теги; tags заменяет все теги
+// изображения.
+type UpdateImageRequest struct {
+	Visibility string    `json:"visibility"`
+	Caption    *string   `json:"caption"`
+	Tags       *[]string `json:"tags"`
+}
+
+// ImageFilter — условия отбора изображений в списках; нулевые поля не ограничивают
+type ImageFilter struct {
+	UserID    string
+	Tags      []string // Нормализованные теги
+	AllTags   bool     // true — изображение должно иметь все теги, false — хотя бы один
+	MimeTypes []string
+	From      time.Time // Время загрузки, включительно
+	To        time.Time // Не включительно
+	MinSize   int64
+	MaxSize   int64
+	Hidden    *bool // Только скрытые (true) или только нескрытые (false)
+	Flagged   *bool // Только отмеченные для проверки или только неотмеченные
+}
+
+// SignURLRequest — параметры подписанной ссылки на файл изображения
+type SignURLRequest struct {
+	TTLSeconds int    `json:"ttl_seconds"` // 0 — срок по умолчанию
+	Variant    string `json:"variant"`     // Пусто — оригинал
+}
+
+// SignedURLResponse — подписанная ссылка и момент, когда она перестанет работать
+type SignedURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// BatchUploadResult — результат обработки одного файла пакетной загрузки
+type BatchUploadResult struct {
+	Index    int             `json:"index"`
+	Filename string          `json:"filename"`
+	Success  bool            `json:"success"`
+	Image    *UploadResponse `json:"image,omitempty"`
+	Error    *ErrorResponse  `json:"error,omitempty"`
+}
+
+type BatchUploadResponse struct {
+	Results   []BatchUploadResult `json:"results"`
+	Total     int                 `json:"total"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+
+	// Error — ошибка чтения тела запроса, после которой файлы не обрабатывались
+	Error *ErrorResponse `json:"error,omitempty"`
 }
 
 type ErrorResponse struct {
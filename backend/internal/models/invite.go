@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Режимы регистрации
+const (
+	RegistrationOpen   = "open"   // Любой может зарегистрироваться как user, инвайт необязателен
+	RegistrationInvite = "invite" // Регистрация только по инвайт-коду
+	RegistrationClosed = "closed" // Регистрация выключена
+)
+
+// Роли пользователей
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+type Invite struct {
+	ID          string              `json:"id" db:"id"`
+	Code        string              `json:"code" db:"code"`
+	Role        string              `json:"role" db:"role"`
+	MaxUses     int                 `json:"max_uses" db:"max_uses"`
+	Uses        int                 `json:"uses" db:"uses"`
+	ExpiresAt   *time.Time          `json:"expires_at" db:"expires_at"`
+	CreatedBy   string              `json:"created_by" db:"created_by"`
+	CreatedAt   time.Time           `json:"created_at" db:"created_at"`
+	RevokedAt   *time.Time          `json:"revoked_at" db:"revoked_at"`
+	Redemptions []*InviteRedemption `json:"redemptions,omitempty" db:"-"`
+}
+
+type InviteRedemption struct {
+	ID         string    `json:"id" db:"id"`
+	InviteID   string    `json:"invite_id" db:"invite_id"`
+	UserID     string    `json:"user_id" db:"user_id"`   // Пусто, если пользователь удален
+	Username   string    `json:"username" db:"username"` // Имя на момент регистрации
+	RedeemedAt time.Time `json:"redeemed_at" db:"redeemed_at"`
+}
+
+type CreateInviteRequest struct {
+	Role           string `json:"role,omitempty"`             // По умолчанию "user"
+	MaxUses        int    `json:"max_uses,omitempty"`         // По умолчанию 1 (одноразовый)
+	ExpiresInHours int    `json:"expires_in_hours,omitempty"` // 0 — без срока действия
+}
+
+type RegistrationInfo struct {
+	Mode string `json:"mode"`
+}
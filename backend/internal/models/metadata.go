@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Что вырезается из EXIF при сохранении файла
+const (
+	StripNone = "none" // Файл сохраняется как есть
+	StripGPS  = "gps"  // Удаляются только координаты
+	StripAll  = "all"  // Удаляются EXIF, XMP и текстовые метаданные целиком
+)
+
+type ImageMetadata struct {
+	Orientation  int        `json:"orientation" db:"orientation"`
+	TakenAt      *time.Time `json:"taken_at,omitempty" db:"taken_at"`
+	CameraMake   string     `json:"camera_make,omitempty" db:"camera_make"`
+	CameraModel  string     `json:"camera_model,omitempty" db:"camera_model"`
+	LensModel    string     `json:"lens_model,omitempty" db:"lens_model"`
+	GPSLatitude  *float64   `json:"gps_latitude,omitempty" db:"gps_latitude"`
+	GPSLongitude *float64   `json:"gps_longitude,omitempty" db:"gps_longitude"`
+	Stripped     string     `json:"stripped" db:"stripped"`
+}
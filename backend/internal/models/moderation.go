@@ -0,0 +1,42 @@
+package models
+
+// Действия администратора над группой изображений
+const (
+	ModerationDelete     = "delete"     // Удалить вместе с файлами
+	ModerationHide       = "hide"       // Скрыть от всех, кроме владельца и администраторов
+	ModerationUnhide     = "unhide"     // Снять скрытие
+	ModerationFlag       = "flag"       // Отметить для проверки
+	ModerationUnflag     = "unflag"     // Снять отметку
+	ModerationVisibility = "visibility" // Сменить видимость на Visibility
+	ModerationMove       = "move"       // Передать пользователю UserID
+)
+
+// AdminImage — изображение в общем списке администратора с именем владельца
+type AdminImage struct {
+	*Image
+
+	Username string `json:"username"`
+}
+
+// BulkImageRequest — действие администратора над изображениями ImageIDs.
+// Visibility нужна для действия visibility, UserID — для move.
+type BulkImageRequest struct {
+	Action     string   `json:"action"`
+	ImageIDs   []string `json:"image_ids"`
+	Visibility string   `json:"visibility"`
+	UserID     string   `json:"user_id"`
+}
+
+// BulkImageResult — результат действия над одним изображением
+type BulkImageResult struct {
+	ID      string         `json:"id"`
+	Success bool           `json:"success"`
+	Error   *ErrorResponse `json:"error,omitempty"`
+}
+
+type BulkImageResponse struct {
+	Results   []BulkImageResult `json:"results"`
+	Total     int               `json:"total"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// Поля сортировки списков
+const (
+	SortCreatedAt = "created_at"
+	SortSize      = "size"
+	SortName      = "name"
+)
+
+// PageRequest — параметры страницы списка с постраничной выборкой по ключу
+type PageRequest struct {
+	Sort   string // Поле сортировки; пусто — created_at
+	Asc    bool   // По умолчанию — по убыванию
+	Limit  int
+	Cursor string // next_cursor предыдущей страницы; пусто — первая страница
+}
+
+// Page — страница списка. NextCursor пуст на последней странице, Total —
+// число элементов, подходящих под фильтры, без учета курсора.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// QuotaLimits — лимиты пользователя на суммарный объем и число изображений;
+// 0 — без ограничения
+type QuotaLimits struct {
+	MaxBytes  int64 `json:"max_bytes"`
+	MaxImages int64 `json:"max_images"`
+}
+
+// QuotaUsage — использование хранилища пользователем и действующие лимиты
+type QuotaUsage struct {
+	BytesUsed  int64 `json:"bytes_used"`
+	ImagesUsed int64 `json:"images_used"`
+	QuotaLimits
+}
+
+// QuotaOverride — лимиты, заданные пользователю администратором:
+// nil — лимит по умолчанию для роли, 0 — без ограничения
+type QuotaOverride struct {
+	MaxBytes  *int64 `json:"max_bytes"`
+	MaxImages *int64 `json:"max_images"`
+}
+
+// UserQuota — квота пользователя для администратора: использование,
+// действующие лимиты и что из них задано вручную
+type UserQuota struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+	QuotaUsage
+	Override QuotaOverride `json:"override"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// ImageSearch — параметры полнотекстового поиска изображений
+type ImageSearch struct {
+	Query  string    // Выражение FTS5
+	UserID string    // Пусто — по всем пользователям
+	From   time.Time // Нулевое — без нижней границы
+	To     time.Time // Не включительно; нулевое — без верхней границы
+	Limit  int
+}
+
+// ImageSearchResult — найденное изображение с подсветкой совпадений
+type ImageSearchResult struct {
+	*Image
+
+	Username   string          `json:"username"`
+	Score      float64         `json:"score"` // Чем больше, тем точнее совпадение
+	Highlights ImageHighlights `json:"highlights"`
+}
+
+// ImageHighlights — текст полей с совпадениями в <mark>…</mark>, остальной
+// текст экранирован для HTML. Для подписи — фрагмент вокруг совпадения.
+type ImageHighlights struct {
+	OriginalName string `json:"original_name"`
+	Caption      string `json:"caption"`
+	Tags         string `json:"tags"`
+}
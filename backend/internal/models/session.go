@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type Session struct {
+	IDHash    string    `json:"-" db:"id_hash"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	LastSeen  time.Time `json:"last_seen" db:"last_seen"`
+	UserAgent string    `json:"user_agent" db:"user_agent"`
+	IP        string    `json:"ip" db:"ip"`
+}
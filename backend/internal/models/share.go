@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// ShareLink — ссылка /s/<slug> на одно или несколько изображений владельца
+type ShareLink struct {
+	ID           string     `json:"id" db:"id"`
+	Slug         string     `json:"slug" db:"slug"`
+	URL          string     `json:"url" db:"-"`
+	UserID       string     `json:"user_id" db:"user_id"`
+	Username     string     `json:"username,omitempty" db:"-"` // Только в списке администратора
+	ImageIDs     []string   `json:"image_ids" db:"-"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	HasPassword  bool       `json:"has_password" db:"-"`
+	MaxViews     int        `json:"max_views" db:"max_views"` // 0 — без ограничения
+	ViewCount    int        `json:"view_count" db:"view_count"`
+	LastViewedAt *time.Time `json:"last_viewed_at" db:"last_viewed_at"`
+	ExpiresAt    *time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt    *time.Time `json:"revoked_at" db:"revoked_at"`
+
+	// Active — ссылку еще можно открыть: не отозвана, не истекла, просмотры не исчерпаны
+	Active bool `json:"active" db:"-"`
+}
+
+type CreateShareLinkRequest struct {
+	ImageIDs       []string `json:"image_ids"`
+	ExpiresInHours int      `json:"expires_in_hours,omitempty"` // 0 — без срока действия
+	MaxViews       int      `json:"max_views,omitempty"`        // 0 — без ограничения
+	Password       string   `json:"password,omitempty"`
+}
+
+// OpenShareRequest — пароль для открытия защищенной ссылки
+type OpenShareRequest struct {
+	Password string `json:"password"`
+}
+
+// SharedImage — изображение в открытой ссылке. URL ведут на файлы через
+// ссылку и действуют ограниченное время.
+type SharedImage struct {
+	ID           string            `json:"id"`
+	OriginalName string            `json:"original_name"`
+	MimeType     string            `json:"mime_type"`
+	Width        int               `json:"width"`
+	Height       int               `json:"height"`
+	URL          string            `json:"url"`
+	Variants     map[string]string `json:"variants,omitempty"`
+}
+
+// SharedView — содержимое ссылки, выдаваемое при засчитанном просмотре
+type SharedView struct {
+	Slug           string         `json:"slug"`
+	Images         []*SharedImage `json:"images"`
+	ExpiresAt      *time.Time     `json:"expires_at"`
+	FilesExpiresAt time.Time      `json:"files_expires_at"` // До этого момента работают URL файлов
+	RemainingViews *int           `json:"remaining_views"`  // nil — без ограничения
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// StoredObject — файл в хранилище, на который ссылается база: оригинал
+// изображения или уменьшенная копия
+type StoredObject struct {
+	Key    string
+	Size   int64
+	SHA256 string // Только у оригиналов с известным хешем
+}
+
+// StoredFile — ссылка изображения на файл по ключу хранилища со сведениями
+// для отдачи клиенту. Для уменьшенной копии Variant — имя варианта,
+// остальные поля относятся к ее изображению.
+type StoredFile struct {
+	Key          string
+	ImageID      string
+	UserID       string
+	Visibility   string
+	AccessToken  string
+	Hidden       bool // Скрыто администратором
+	Variant      string
+	MimeType     string
+	Size         int64
+	SHA256       string
+	OriginalName string
+	CreatedAt    time.Time
+}
@@ -0,0 +1,7 @@
+package models
+
+// TagCount — тег пользователя и число его изображений с этим тегом
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
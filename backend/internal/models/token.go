@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Области действия API токенов
+const (
+	TokenScopeUpload = "upload"
+	TokenScopeRead   = "read"
+)
+
+var TokenScopes = []string{TokenScopeUpload, TokenScopeRead}
+
+type APIToken struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     string     `json:"user_id" db:"user_id"`
+	Name       string     `json:"name" db:"name"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// HasScope проверяет, что токену выдана указанная область
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateTokenRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"` // 0 — без срока действия
+}
+
+type CreateTokenResponse struct {
+	Token    string   `json:"token"` // Показывается только один раз
+	APIToken APIToken `json:"api_token"`
+}
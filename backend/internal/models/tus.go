@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// TusUpload — незавершенная (или только что собранная) загрузка по протоколу tus
+type TusUpload struct {
+	ID         string    `json:"id" db:"id"`
+	UserID     string    `json:"user_id" db:"user_id"`
+	Length     int64     `json:"length" db:"length"`
+	Offset     int64     `json:"offset" db:"upload_offset"`
+	Filename   string    `json:"filename" db:"filename"`
+	Filetype   string    `json:"filetype" db:"filetype"`
+	Visibility string    `json:"visibility" db:"visibility"`       // Пусто — по умолчанию
+	AlbumID    string    `json:"album_id" db:"album_id"`           // Пусто — без альбома
+	Tags       []string  `json:"tags" db:"tags"`                   // Хранятся через запятую
+	Caption    string    `json:"caption" db:"caption"`             // Подпись будущего изображения
+	ImageID    string    `json:"image_id,omitempty" db:"image_id"` // Заполняется после сборки
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// Complete сообщает, что получены все байты загрузки
+func (u *TusUpload) Complete() bool {
+	return u.Offset == u.Length
+}
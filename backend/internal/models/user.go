@@ -8,6 +8,55 @@ type User struct {
 	PasswordHash string    `json:"-" db:"password_hash"`
 	Role         string    `json:"role" db:"role"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+
+	// DisabledAt — когда администратор отключил учетную запись; nil — активна
+	DisabledAt *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
+	// PasswordResetRequired — пароль сброшен администратором и должен быть
+	// сменен через /api/auth/password до входа
+	PasswordResetRequired bool `json:"password_reset_required,omitempty" db:"password_reset_required"`
+}
+
+// Способы поступить с изображениями удаляемого пользователя
+const (
+	UserImagesDelete   = "delete"
+	UserImagesReassign = "reassign"
+)
+
+// CreateUserRequest — пользователь, создаваемый администратором
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role,omitempty"` // По умолчанию user
+	// Потребовать сменить пароль при первом входе
+	PasswordResetRequired bool `json:"password_reset_required,omitempty"`
+}
+
+// UpdateUserRequest — изменение роли и отключение учетной записи; nil — без изменений
+type UpdateUserRequest struct {
+	Role     *string `json:"role"`
+	Disabled *bool   `json:"disabled"`
+}
+
+// ResetPasswordRequest — новый пароль; пусто — сгенерировать временный
+type ResetPasswordRequest struct {
+	Password string `json:"password,omitempty"`
+}
+
+// ResetPasswordResponse — временный пароль, который нужно передать пользователю
+type ResetPasswordResponse struct {
+	Password string `json:"password"`
+}
+
+// ChangePasswordRequest — смена пароля пользователем по текущему паролю
+type ChangePasswordRequest struct {
+	Username        string `json:"username"`
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// UserFilter — условия отбора пользователей в списке администратора
+type UserFilter struct {
+	Query string // Часть имени пользователя
 }
 
 type UserWithImageCount struct {
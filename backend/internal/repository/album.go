@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"image-uploader-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAlbumNotFound    = errors.New("album not found")
+	ErrNotInAlbum       = errors.New("image is not in the album")
+	ErrAlbumOrderChange = errors.New("album images changed")
+)
+
+type AlbumRepository struct {
+	db *sql.DB
+}
+
+func NewAlbumRepository(db *sql.DB) *AlbumRepository {
+	return &AlbumRepository{db: db}
+}
+
+// albumColumns включает число изображений альбома
+const albumColumns = `a.id, a.user_id, a.name, a.description, a.visibility, a.access_token, a.cover_image_id,
+	(SELECT COUNT(*) FROM album_images ai WHERE ai.album_id = a.id), a.created_at, a.updated_at`
+
+func (r *AlbumRepository) Create(album *models.Album) error {
+	album.ID = uuid.New().String()
+	album.CreatedAt = time.Now()
+	album.UpdatedAt = album.CreatedAt
+
+	query := `
+		INSERT INTO albums (id, user_id, name, description, visibility, access_token, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, album.ID, album.UserID, album.Name, album.Description, album.Visibility,
+		album.AccessToken, album.CreatedAt, album.UpdatedAt)
+	return err
+}
+
+func (r *AlbumRepository) GetByID(id string) (*models.Album, error) {
+	album, err := scanAlbum(r.db.QueryRow(`SELECT `+albumColumns+` FROM albums a WHERE a.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAlbumNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return album, nil
+}
+
+// GetByUserID возвращает альбомы пользователя по имени
+func (r *AlbumRepository) GetByUserID(userID string) ([]*models.Album, error) {
+	rows, err := r.db.Query(`SELECT `+albumColumns+` FROM albums a WHERE a.user_id = ? ORDER BY a.name, a.created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var albums []*models.Album
+	for rows.Next() {
+		album, err := scanAlbum(rows)
+		if err != nil {
+			return nil, err
+		}
+		albums = append(albums, album)
+	}
+
+	return albums, rows.Err()
+}
+
+// Update сохраняет изменяемые свойства альбома
+func (r *AlbumRepository) Update(album *models.Album) error {
+	album.UpdatedAt = time.Now()
+
+	var cover any
+	if album.CoverImageID != "" {
+		cover = album.CoverImageID
+	}
+
+	result, err := r.db.Exec(`
+		UPDATE albums SET name = ?, description = ?, visibility = ?, access_token = ?, cover_image_id = ?, updated_at = ?
+		WHERE id = ?
+	`, album.Name, album.Description, album.Visibility, album.AccessToken, cover, album.UpdatedAt, album.ID)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAlbumNotFound)
+}
+
+// Delete удаляет альбом; изображения остаются
+func (r *AlbumRepository) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM albums WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrAlbumNotFound)
+}
+
+// ImageIDs возвращает изображения альбома по порядку
+func (r *AlbumRepository) ImageIDs(albumID string) ([]string, error) {
+	rows, err := r.db.Query(`SELECT image_id FROM album_images WHERE album_id = ? ORDER BY position`, albumID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// AddImages добавляет изображения в конец альбома; уже входящие пропускаются
+func (r *AlbumRepository) AddImages(albumID string, imageIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		INSERT OR IGNORE INTO album_images (album_id, image_id, position, added_at)
+		SELECT ?, ?, COALESCE(MAX(position) + 1, 0), ? FROM album_images WHERE album_id = ?
+	`
+	for _, imageID := range imageIDs {
+		if _, err := tx.Exec(query, albumID, imageID, now, albumID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE albums SET updated_at = ? WHERE id = ?`, now, albumID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RemoveImage убирает изображение из альбома; если оно было обложкой,
+// обложкой снова становится первое изображение
+func (r *AlbumRepository) RemoveImage(albumID, imageID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM album_images WHERE album_id = ? AND image_id = ?`, albumID, imageID)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, ErrNotInAlbum); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE albums SET updated_at = ?,
+			cover_image_id = CASE WHEN cover_image_id = ? THEN NULL ELSE cover_image_id END
+		WHERE id = ?
+	`, time.Now(), imageID, albumID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Reorder задает порядок изображений. imageIDs должен содержать ровно
+// изображения альбома, иначе возвращается ErrAlbumOrderChange.
+func (r *AlbumRepository) Reorder(albumID string, imageIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM album_images WHERE album_id = ?`, albumID).Scan(&count); err != nil {
+		return err
+	}
+	if count != len(imageIDs) {
+		return ErrAlbumOrderChange
+	}
+
+	for i, imageID := range imageIDs {
+		result, err := tx.Exec(`UPDATE album_images SET position = ? WHERE album_id = ? AND image_id = ?`, i, albumID, imageID)
+		if err != nil {
+			return err
+		}
+		if err := requireAffected(result, ErrAlbumOrderChange); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE albums SET updated_at = ? WHERE id = ?`, time.Now(), albumID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// requireAffected возвращает notFound, если запрос не изменил ни одной строки
+func requireAffected(result sql.Result, notFound error) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
+
+func scanAlbum(row rowScanner) (*models.Album, error) {
+	album := &models.Album{}
+	var cover sql.NullString
+
+	err := row.Scan(
+		&album.ID, &album.UserID, &album.Name, &album.Description, &album.Visibility, &album.AccessToken,
+		&cover, &album.ImageCount, &album.CreatedAt, &album.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	album.CoverImageID = cover.String
+	return album, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"image-uploader-backend/internal/database"
+	"image-uploader-backend/migrations"
+)
+
+// newTestDB открывает пустую базу в памяти со всеми миграциями. Соединение
+// одно: у каждого соединения с :memory: своя отдельная база.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := openTestDB(t, ":memory:")
+	db.SetMaxOpenConns(1)
+	migrateTestDB(t, db)
+	return db
+}
+
+// newTestFileDB открывает пустую базу во временном файле. Нужна тестам
+// параллельных запросов: с ней работают несколько соединений сразу.
+func newTestFileDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db := openTestDB(t, filepath.Join(t.TempDir(), "test.db"))
+	migrateTestDB(t, db)
+	return db
+}
+
+func openTestDB(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func migrateTestDB(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	if err := database.Migrate(db, migrations.FS); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+}
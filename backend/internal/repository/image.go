@@ -2,12 +2,17 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"image-uploader-backend/internal/models"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrImageNotFound = errors.New("image not found")
+
 type ImageRepository struct {
 	db *sql.DB
 }
@@ -16,30 +21,222 @@ func NewImageRepository(db *sql.DB) *ImageRepository {
 	return &ImageRepository{db: db}
 }
 
-func (r *ImageRepository) Create(image *models.Image) error {
+const imageColumns = `id, user_id, original_name, caption, file_name, storage_key, mime_type, size, width, height, sha256, visibility, access_token, created_at, hidden_at, flagged_at`
+
+// Create сохраняет изображение и, если они есть, его метаданные и теги в одной транзакции.
+// Для изображения с хешем увеличивается счетчик ссылок на файл содержимого.
+// Если после сохранения использование хранилища пользователя превышает
+// limits, транзакция откатывается с ErrQuotaExceeded.
+func (r *ImageRepository) Create(image *models.Image, limits models.QuotaLimits) error {
 	image.ID = uuid.New().String()
-	image.CreatedAt = time.Now()
+	// created_at сравнивается в SQL (поиск по датам), поэтому храним его в UTC
+	image.CreatedAt = time.Now().UTC()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if image.SHA256 != "" {
+		query := `
+			INSERT INTO blobs (sha256, storage_key, size, ref_count, created_at)
+			VALUES (?, ?, ?, 1, ?)
+			ON CONFLICT (sha256) DO UPDATE SET ref_count = ref_count + 1
+		`
+		_, err := tx.Exec(query, image.SHA256, image.StorageKey, image.Size, image.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
 
 	query := `
-		INSERT INTO images (id, user_id, original_name, file_name, file_path, mime_type, size, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO images (id, user_id, original_name, caption, file_name, storage_key, mime_type, size, width, height, sha256, visibility, access_token, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query, image.ID, image.UserID, image.OriginalName, image.FileName, image.FilePath,
-		image.MimeType, image.Size, image.CreatedAt)
+	_, err = tx.Exec(query, image.ID, image.UserID, image.OriginalName, image.Caption, image.FileName, image.StorageKey,
+		image.MimeType, image.Size, image.Width, image.Height, image.SHA256, image.Visibility, image.AccessToken, image.CreatedAt)
+	if err != nil {
+		return err
+	}
 
-	return err
+	if image.Metadata != nil {
+		if err := insertMetadata(tx, image.ID, image.Metadata); err != nil {
+			return err
+		}
+	}
+	if err := insertTags(tx, image.ID, image.Tags); err != nil {
+		return err
+	}
+	if err := checkQuota(tx, image.UserID, limits); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (r *ImageRepository) GetByUserID(userID string) ([]*models.Image, error) {
+// imageSorts — выражения сортировки списка изображений
+var imageSorts = map[string]string{
+	models.SortCreatedAt: "images.created_at",
+	models.SortSize:      "images.size",
+	models.SortName:      "images.original_name",
+}
+
+// List возвращает страницу изображений, отобранных filter, и их общее число
+func (r *ImageRepository) List(filter models.ImageFilter, page models.PageRequest) (*models.Page[*models.Image], error) {
+	result, _, err := r.list(filter, page, false)
+	return result, err
+}
+
+// ListWithOwner — как List, но каждое изображение с именем владельца,
+// полученным тем же запросом
+func (r *ImageRepository) ListWithOwner(filter models.ImageFilter, page models.PageRequest) (*models.Page[*models.AdminImage], error) {
+	images, owners, err := r.list(filter, page, true)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Page[*models.AdminImage]{
+		Items:      make([]*models.AdminImage, len(images.Items)),
+		NextCursor: images.NextCursor,
+		Total:      images.Total,
+	}
+	for i, image := range images.Items {
+		result.Items[i] = &models.AdminImage{Image: image, Username: owners[i]}
+	}
+	return result, nil
+}
+
+// list выбирает страницу изображений; с withOwner возвращает и имена
+// владельцев в том же порядке
+func (r *ImageRepository) list(filter models.ImageFilter, page models.PageRequest, withOwner bool) (*models.Page[*models.Image], []string, error) {
+	sortExpr, ok := imageSorts[page.Sort]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown sort %q", page.Sort)
+	}
+	k := keyset{sortExpr: sortExpr, idExpr: "images.id", page: page}
+
+	where, args := imageConditions(filter)
+
+	result := &models.Page[*models.Image]{}
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM images WHERE `+strings.Join(where, " AND "), args...).Scan(&result.Total)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	after, afterArgs, err := k.after()
+	if err != nil {
+		return nil, nil, err
+	}
+	if after != "" {
+		where = append(where, after)
+		args = append(args, afterArgs...)
+	}
+	orderBy, orderArgs := k.orderBy()
+
+	columns := imageColumns + `, ` + k.column()
+	if withOwner {
+		columns += `, (SELECT username FROM users WHERE users.id = images.user_id)`
+	}
+	query := `SELECT ` + columns + ` FROM images WHERE ` + strings.Join(where, " AND ") + orderBy
+	rows, err := r.db.Query(query, append(args, orderArgs...)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var values, ids, owners []string
+	result.Items = []*models.Image{}
+	for rows.Next() {
+		var value string
+		var owner sql.NullString
+		extra := []any{&value}
+		if withOwner {
+			extra = append(extra, &owner)
+		}
+		image, err := scanImage(rows, extra...)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Items = append(result.Items, image)
+		values = append(values, value)
+		ids = append(ids, image.ID)
+		owners = append(owners, owner.String)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	result.Items, result.NextCursor = trim(k, result.Items, values, ids)
+	return result, owners[:len(result.Items)], nil
+}
+
+// imageConditions возвращает условия WHERE для фильтра и их аргументы
+func imageConditions(filter models.ImageFilter) ([]string, []any) {
+	where := []string{"1 = 1"}
+	var args []any
+
+	if filter.UserID != "" {
+		where = append(where, "images.user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if cond, condArgs := tagCondition(filter); cond != "" {
+		where = append(where, cond)
+		args = append(args, condArgs...)
+	}
+	if len(filter.MimeTypes) > 0 {
+		where = append(where, "images.mime_type IN ("+strings.TrimSuffix(strings.Repeat("?,", len(filter.MimeTypes)), ",")+")")
+		for _, mimeType := range filter.MimeTypes {
+			args = append(args, mimeType)
+		}
+	}
+	// created_at хранится в UTC, поэтому границы сравниваются тоже в UTC
+	if !filter.From.IsZero() {
+		where = append(where, "images.created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		where = append(where, "images.created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+	if filter.MinSize > 0 {
+		where = append(where, "images.size >= ?")
+		args = append(args, filter.MinSize)
+	}
+	if filter.MaxSize > 0 {
+		where = append(where, "images.size <= ?")
+		args = append(args, filter.MaxSize)
+	}
+	if filter.Hidden != nil {
+		where = append(where, nullCondition("images.hidden_at", *filter.Hidden))
+	}
+	if filter.Flagged != nil {
+		where = append(where, nullCondition("images.flagged_at", *filter.Flagged))
+	}
+
+	return where, args
+}
+
+// nullCondition — условие «значение задано» (set) или «не задано» для столбца
+func nullCondition(column string, set bool) string {
+	if set {
+		return column + " IS NOT NULL"
+	}
+	return column + " IS NULL"
+}
+
+// GetByAlbumID возвращает изображения альбома в порядке альбома
+func (r *ImageRepository) GetByAlbumID(albumID string) ([]*models.Image, error) {
 	query := `
-		SELECT id, user_id, original_name, file_name, file_path, mime_type, size, created_at 
-		FROM images 
-		WHERE user_id = ?
-		ORDER BY created_at DESC
+		SELECT ` + imageColumns + `
+		FROM images
+		JOIN album_images ai ON ai.image_id = images.id
+		WHERE ai.album_id = ?
+		ORDER BY ai.position
 	`
 
-	rows, err := r.db.Query(query, userID)
+	rows, err := r.db.Query(query, albumID)
 	if err != nil {
 		return nil, err
 	}
@@ -47,16 +244,476 @@ func (r *ImageRepository) GetByUserID(userID string) ([]*models.Image, error) {
 
 	var images []*models.Image
 	for rows.Next() {
-		image := &models.Image{}
+		image, err := scanImage(rows)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+
+	return images, rows.Err()
+}
+
+func (r *ImageRepository) GetByID(id string) (*models.Image, error) {
+	image, err := scanImage(r.db.QueryRow(`SELECT `+imageColumns+` FROM images WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// GetByUserAndHash возвращает самое раннее изображение пользователя с таким содержимым
+func (r *ImageRepository) GetByUserAndHash(userID, sha256 string) (*models.Image, error) {
+	query := `SELECT ` + imageColumns + ` FROM images WHERE user_id = ? AND sha256 = ? ORDER BY created_at LIMIT 1`
+
+	image, err := scanImage(r.db.QueryRow(query, userID, sha256))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// IDsByUserID возвращает id всех изображений пользователя
+func (r *ImageRepository) IDsByUserID(userID string) ([]string, error) {
+	rows, err := r.db.Query(`SELECT id FROM images WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// GetFilesByKey находит все ссылки на файл по ключу хранилища: оригиналы
+// и уменьшенные копии. Файл может быть общим для нескольких изображений
+// с разной видимостью, поэтому первыми идут публичные нескрытые, затем по
+// времени создания.
+func (r *ImageRepository) GetFilesByKey(key string) ([]*models.StoredFile, error) {
+	// ORDER BY составного запроса принимает только имена столбцов,
+	// поэтому сортировка по выражению вынесена во внешний запрос
+	query := `
+		SELECT * FROM (
+			SELECT storage_key, id, user_id, visibility, access_token, hidden_at IS NOT NULL AS hidden, '' AS variant,
+				mime_type, size, sha256, original_name, created_at
+			FROM images
+			WHERE storage_key = ?
+			UNION ALL
+			SELECT v.storage_key, i.id, i.user_id, i.visibility, i.access_token, i.hidden_at IS NOT NULL, v.name,
+				v.mime_type, v.size, i.sha256, i.original_name, v.created_at
+			FROM image_variants v
+			JOIN images i ON i.id = v.image_id
+			WHERE v.storage_key = ?
+		)
+		ORDER BY hidden, visibility <> 'public', created_at
+	`
+
+	rows, err := r.db.Query(query, key, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []*models.StoredFile
+	for rows.Next() {
+		file := &models.StoredFile{}
 		err := rows.Scan(
-			&image.ID, &image.UserID, &image.OriginalName, &image.FileName, &image.FilePath,
-			&image.MimeType, &image.Size, &image.CreatedAt,
+			&file.Key, &file.ImageID, &file.UserID, &file.Visibility, &file.AccessToken, &file.Hidden, &file.Variant,
+			&file.MimeType, &file.Size, &file.SHA256, &file.OriginalName, &file.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		images = append(images, image)
+		files = append(files, file)
+	}
+
+	return files, rows.Err()
+}
+
+// UpdateVisibility меняет видимость изображения и токен доступа к нему
+func (r *ImageRepository) UpdateVisibility(id, visibility, accessToken string) error {
+	result, err := r.db.Exec(`UPDATE images SET visibility = ?, access_token = ? WHERE id = ?`, visibility, accessToken, id)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrImageNotFound
+	}
+	return nil
+}
+
+func scanImage(row rowScanner, extra ...any) (*models.Image, error) {
+	image := &models.Image{}
+	var hiddenAt, flaggedAt sql.NullTime
+	dest := []any{
+		&image.ID, &image.UserID, &image.OriginalName, &image.Caption, &image.FileName, &image.StorageKey,
+		&image.MimeType, &image.Size, &image.Width, &image.Height, &image.SHA256,
+		&image.Visibility, &image.AccessToken, &image.CreatedAt, &hiddenAt, &flaggedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	image.HiddenAt = nullTimePtr(hiddenAt)
+	image.FlaggedAt = nullTimePtr(flaggedAt)
+	return image, nil
+}
+
+// UpdateCaption меняет подпись изображения
+func (r *ImageRepository) UpdateCaption(id, caption string) error {
+	result, err := r.db.Exec(`UPDATE images SET caption = ? WHERE id = ?`, caption, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrImageNotFound)
+}
+
+// SetHidden скрывает изображение с момента hiddenAt; nil снимает скрытие
+func (r *ImageRepository) SetHidden(id string, hiddenAt *time.Time) error {
+	result, err := r.db.Exec(`UPDATE images SET hidden_at = ? WHERE id = ?`, hiddenAt, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrImageNotFound)
+}
+
+// SetFlagged отмечает изображение для проверки с момента flaggedAt; nil снимает отметку
+func (r *ImageRepository) SetFlagged(id string, flaggedAt *time.Time) error {
+	result, err := r.db.Exec(`UPDATE images SET flagged_at = ? WHERE id = ?`, flaggedAt, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrImageNotFound)
+}
+
+// Move передает изображение пользователю userID. Изображение убирается
+// из альбомов и ссылок прежнего владельца: они остаются у него, а чужие
+// изображения в них быть не могут. Квоты обоих пользователей пересчитывают
+// триггеры user_quotas.
+func (r *ImageRepository) Move(id, userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE images SET user_id = ? WHERE id = ?`, userID, id)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, ErrImageNotFound); err != nil {
+		return err
+	}
+
+	queries := []string{
+		`DELETE FROM album_images WHERE image_id = ? AND album_id IN (SELECT id FROM albums WHERE user_id <> ?)`,
+		`UPDATE albums SET cover_image_id = NULL WHERE cover_image_id = ? AND user_id <> ?`,
+		`DELETE FROM share_link_images WHERE image_id = ? AND share_id IN (SELECT id FROM share_links WHERE user_id <> ?)`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, id, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete удаляет строку изображения и уменьшает счетчик ссылок на его файл.
+// Если ссылок больше не осталось, в той же транзакции объекты ставятся в очередь
+// на удаление, чтобы они не остались сиротами при сбое. Возвращает true,
+// если объекты больше никому не нужны и их можно удалять из хранилища.
+func (r *ImageRepository) Delete(id string, keys []string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var sha256 string
+	err = tx.QueryRow(`DELETE FROM images WHERE id = ? RETURNING sha256`, id).Scan(&sha256)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, ErrImageNotFound
+	}
+	if err != nil {
+		return false, err
+	}
+
+	released, err := releaseBlob(tx, sha256)
+	if err != nil {
+		return false, err
+	}
+
+	if released {
+		if err := queueDeletions(tx, keys); err != nil {
+			return false, err
+		}
+	}
+
+	return released, tx.Commit()
+}
+
+// releaseBlob снимает одну ссылку с файла содержимого и удаляет запись,
+// когда ссылок не осталось. Изображения без хеша владеют файлом единолично.
+func releaseBlob(tx *sql.Tx, sha256 string) (bool, error) {
+	if sha256 == "" {
+		return true, nil
+	}
+
+	var refCount int
+	err := tx.QueryRow(`UPDATE blobs SET ref_count = ref_count - 1 WHERE sha256 = ? RETURNING ref_count`, sha256).
+		Scan(&refCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if refCount > 0 {
+		return false, nil
+	}
+
+	_, err = tx.Exec(`DELETE FROM blobs WHERE sha256 = ?`, sha256)
+	return err == nil, err
+}
+
+// GetPendingDeletions возвращает объекты, которые ещё не удалось удалить из хранилища
+func (r *ImageRepository) GetPendingDeletions() ([]string, error) {
+	rows, err := r.db.Query(`SELECT storage_key FROM storage_deletions ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *ImageRepository) CompleteDeletion(key string) error {
+	_, err := r.db.Exec(`DELETE FROM storage_deletions WHERE storage_key = ?`, key)
+	return err
+}
+
+func queueDeletions(ex execer, keys []string) error {
+	now := time.Now()
+	for _, key := range keys {
+		_, err := ex.Exec(`INSERT OR IGNORE INTO storage_deletions (storage_key, created_at) VALUES (?, ?)`, key, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storageKeyTables — таблицы, в колонке storage_key которых до перехода
+// на ключи хранилища лежали пути на диске
+var storageKeyTables = []string{"images", "image_variants", "blobs", "storage_deletions"}
+
+// BackfillStorageKeys один раз переводит пути на диске, оставшиеся от
+// строк, созданных до миграции 0010, в ключи хранилища с помощью keyFor.
+// Возвращает число обновленных значений и пути, которые keyFor не смог
+// перевести (ok == false). Если такие пути есть, ничего не сохраняется,
+// чтобы перевод можно было повторить с другими настройками.
+func (r *ImageRepository) BackfillStorageKeys(keyFor func(path string) (string, bool)) (int, []string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var pending int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM storage_key_backfill`).Scan(&pending)
+	if err != nil || pending == 0 {
+		return 0, nil, err
+	}
+
+	updated := 0
+	var skipped []string
+	for _, table := range storageKeyTables {
+		rows, err := tx.Query(`SELECT DISTINCT storage_key FROM ` + table)
+		if err != nil {
+			return 0, nil, err
+		}
+		var paths []string
+		for rows.Next() {
+			var path string
+			if err := rows.Scan(&path); err != nil {
+				rows.Close()
+				return 0, nil, err
+			}
+			paths = append(paths, path)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return 0, nil, err
+		}
+
+		for _, path := range paths {
+			key, ok := keyFor(path)
+			if !ok {
+				skipped = append(skipped, path)
+				continue
+			}
+			if key == path {
+				continue
+			}
+			res, err := tx.Exec(`UPDATE `+table+` SET storage_key = ? WHERE storage_key = ?`, key, path)
+			if err != nil {
+				return 0, nil, err
+			}
+			n, _ := res.RowsAffected()
+			updated += int(n)
+		}
+	}
+	if len(skipped) > 0 {
+		return 0, skipped, nil
+	}
+
+	if _, err := tx.Exec(`DELETE FROM storage_key_backfill`); err != nil {
+		return 0, nil, err
+	}
+	return updated, nil, tx.Commit()
+}
+
+func (r *ImageRepository) CreateVariant(variant *models.ImageVariant) error {
+	variant.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO image_variants (image_id, name, file_name, storage_key, mime_type, width, height, size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, variant.ImageID, variant.Name, variant.FileName, variant.StorageKey,
+		variant.MimeType, variant.Width, variant.Height, variant.Size, variant.CreatedAt)
+	return err
+}
+
+// GetVariants возвращает варианты для набора изображений, сгруппированные по image_id
+func (r *ImageRepository) GetVariants(imageIDs []string) (map[string][]*models.ImageVariant, error) {
+	result := make(map[string][]*models.ImageVariant)
+	if len(imageIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(imageIDs)), ",")
+	args := make([]any, len(imageIDs))
+	for i, id := range imageIDs {
+		args[i] = id
+	}
+
+	query := `
+		SELECT image_id, name, file_name, storage_key, mime_type, width, height, size, created_at
+		FROM image_variants
+		WHERE image_id IN (` + placeholders + `)
+		ORDER BY width
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		variant := &models.ImageVariant{}
+		err := rows.Scan(
+			&variant.ImageID, &variant.Name, &variant.FileName, &variant.StorageKey, &variant.MimeType,
+			&variant.Width, &variant.Height, &variant.Size, &variant.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		result[variant.ImageID] = append(result[variant.ImageID], variant)
+	}
+
+	return result, rows.Err()
+}
+
+func insertMetadata(ex execer, imageID string, meta *models.ImageMetadata) error {
+	query := `
+		INSERT INTO image_metadata (image_id, orientation, taken_at, camera_make, camera_model, lens_model,
+			gps_latitude, gps_longitude, stripped)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := ex.Exec(query, imageID, meta.Orientation, meta.TakenAt, meta.CameraMake, meta.CameraModel,
+		meta.LensModel, meta.GPSLatitude, meta.GPSLongitude, meta.Stripped)
+	return err
+}
+
+// GetMetadata возвращает метаданные для набора изображений по image_id.
+// У изображений, загруженных до появления метаданных, записи нет.
+func (r *ImageRepository) GetMetadata(imageIDs []string) (map[string]*models.ImageMetadata, error) {
+	result := make(map[string]*models.ImageMetadata)
+	if len(imageIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(imageIDs)), ",")
+	args := make([]any, len(imageIDs))
+	for i, id := range imageIDs {
+		args[i] = id
+	}
+
+	query := `
+		SELECT image_id, orientation, taken_at, camera_make, camera_model, lens_model,
+			gps_latitude, gps_longitude, stripped
+		FROM image_metadata
+		WHERE image_id IN (` + placeholders + `)
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imageID string
+		var takenAt sql.NullTime
+		var latitude, longitude sql.NullFloat64
+		meta := &models.ImageMetadata{}
+		err := rows.Scan(
+			&imageID, &meta.Orientation, &takenAt, &meta.CameraMake, &meta.CameraModel, &meta.LensModel,
+			&latitude, &longitude, &meta.Stripped,
+		)
+		if err != nil {
+			return nil, err
+		}
+		meta.TakenAt = nullTimePtr(takenAt)
+		if latitude.Valid && longitude.Valid {
+			meta.GPSLatitude = &latitude.Float64
+			meta.GPSLongitude = &longitude.Float64
+		}
+		result[imageID] = meta
 	}
 
-	return images, nil
+	return result, rows.Err()
 }
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"image-uploader-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInviteUnavailable — инвайт не найден, отозван, истёк или исчерпан
+var ErrInviteUnavailable = errors.New("invite is not available")
+
+type InviteRepository struct {
+	db *sql.DB
+}
+
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{db: db}
+}
+
+const inviteColumns = `id, code, role, max_uses, uses, expires_at, created_by, created_at, revoked_at`
+
+func (r *InviteRepository) Create(invite *models.Invite) error {
+	invite.ID = uuid.New().String()
+	invite.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO invites (id, code, role, max_uses, uses, expires_at, created_by, created_at)
+		VALUES (?, ?, ?, ?, 0, ?, ?, ?)
+	`
+
+	// expires_at сравнивается в SQL, поэтому храним его в UTC
+	var expiresAt any
+	if invite.ExpiresAt != nil {
+		expiresAt = invite.ExpiresAt.UTC()
+	}
+
+	_, err := r.db.Exec(query, invite.ID, invite.Code, invite.Role, invite.MaxUses, expiresAt,
+		invite.CreatedBy, invite.CreatedAt)
+	return err
+}
+
+// GetAllWithRedemptions возвращает все инвайты вместе с тем, кто по ним зарегистрировался
+func (r *InviteRepository) GetAllWithRedemptions() ([]*models.Invite, error) {
+	rows, err := r.db.Query(`SELECT ` + inviteColumns + ` FROM invites ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []*models.Invite
+	byID := make(map[string]*models.Invite)
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, invite)
+		byID[invite.ID] = invite
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, invite_id, user_id, username, redeemed_at
+		FROM invite_redemptions
+		ORDER BY redeemed_at
+	`
+
+	redemptionRows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer redemptionRows.Close()
+
+	for redemptionRows.Next() {
+		redemption := &models.InviteRedemption{}
+		var userID sql.NullString
+		err := redemptionRows.Scan(
+			&redemption.ID, &redemption.InviteID, &userID, &redemption.Username, &redemption.RedeemedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		redemption.UserID = userID.String
+		if invite, ok := byID[redemption.InviteID]; ok {
+			invite.Redemptions = append(invite.Redemptions, redemption)
+		}
+	}
+
+	return invites, redemptionRows.Err()
+}
+
+// Revoke отзывает инвайт. Возвращает sql.ErrNoRows, если активного инвайта нет.
+func (r *InviteRepository) Revoke(id string) error {
+	result, err := r.db.Exec(`UPDATE invites SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CreateUserWithInvite в одной транзакции занимает одно использование инвайта,
+// создаёт пользователя с ролью из инвайта и записывает факт использования.
+// Если пользователя создать не удалось, использование инвайта откатывается.
+func (r *InviteRepository) CreateUserWithInvite(code string, user *models.User) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var inviteID, role string
+	query := `
+		UPDATE invites SET uses = uses + 1
+		WHERE code = ?
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > ?)
+		  AND uses < max_uses
+		RETURNING id, role
+	`
+	err = tx.QueryRow(query, code, now.UTC()).Scan(&inviteID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInviteUnavailable
+	}
+	if err != nil {
+		return err
+	}
+
+	user.Role = role
+	if err := insertUser(tx, user); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO invite_redemptions (id, invite_id, user_id, username, redeemed_at) VALUES (?, ?, ?, ?, ?)`,
+		uuid.New().String(), inviteID, user.ID, user.Username, now)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanInvite(row rowScanner) (*models.Invite, error) {
+	invite := &models.Invite{}
+	var createdBy sql.NullString
+	var expiresAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&invite.ID, &invite.Code, &invite.Role, &invite.MaxUses, &invite.Uses,
+		&expiresAt, &createdBy, &invite.CreatedAt, &revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	invite.CreatedBy = createdBy.String
+	invite.ExpiresAt = nullTimePtr(expiresAt)
+	invite.RevokedAt = nullTimePtr(revokedAt)
+
+	return invite, nil
+}
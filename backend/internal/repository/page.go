@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor — позиция последнего элемента страницы: значение поля сортировки
+// в том виде, в каком оно хранится в базе, и id для одинаковых значений.
+// Поле и направление сортировки входят в курсор, чтобы курсор нельзя было
+// применить к другой сортировке.
+type cursor struct {
+	Sort  string `json:"s"`
+	Asc   bool   `json:"a,omitempty"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// keyset строит постраничную выборку по ключу (sortExpr, idExpr): следующая
+// страница начинается строго после последнего элемента предыдущей, поэтому
+// вставки и удаления между запросами не сдвигают страницы.
+type keyset struct {
+	sortExpr string
+	idExpr   string
+	page     models.PageRequest
+}
+
+// column — выражение для SELECT со значением сортировки для курсора
+func (k keyset) column() string {
+	return `CAST(` + k.sortExpr + ` AS TEXT)`
+}
+
+// after возвращает условие «после курсора» и его аргументы; для первой
+// страницы условие пустое
+func (k keyset) after() (string, []any, error) {
+	if k.page.Cursor == "" {
+		return "", nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(k.page.Cursor)
+	if err != nil {
+		return "", nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.Sort != k.page.Sort || c.Asc != k.page.Asc {
+		return "", nil, ErrInvalidCursor
+	}
+
+	op := "<"
+	if k.page.Asc {
+		op = ">"
+	}
+	cond := fmt.Sprintf("(%[1]s %[3]s ? OR (%[1]s = ? AND %[2]s %[3]s ?))", k.sortExpr, k.idExpr, op)
+	return cond, []any{c.Value, c.Value, c.ID}, nil
+}
+
+// orderBy — сортировка и лимит страницы; выбирается на строку больше,
+// чтобы узнать, есть ли следующая страница
+func (k keyset) orderBy() (string, []any) {
+	dir := "DESC"
+	if k.page.Asc {
+		dir = "ASC"
+	}
+	return fmt.Sprintf(" ORDER BY %s %s, %s %s LIMIT ?", k.sortExpr, dir, k.idExpr, dir), []any{k.page.Limit + 1}
+}
+
+// trim обрезает выборку до страницы и возвращает курсор следующей страницы
+// (пусто, если ее нет). values и ids — значения сортировки и id строк выборки.
+func trim[T any](k keyset, items []T, values, ids []string) ([]T, string) {
+	if len(items) <= k.page.Limit {
+		return items, ""
+	}
+
+	last := k.page.Limit - 1
+	raw, _ := json.Marshal(cursor{Sort: k.page.Sort, Asc: k.page.Asc, Value: values[last], ID: ids[last]})
+	return items[:k.page.Limit], base64.RawURLEncoding.EncodeToString(raw)
+}
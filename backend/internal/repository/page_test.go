@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"image-uploader-backend/internal/models"
+)
+
+// pageTestImage — изображение для тестов постраничной выборки
+type pageTestImage struct {
+	id        string
+	name      string
+	size      int64
+	createdAt time.Time
+}
+
+// seedPageImages создает пользователя и его изображения с повторяющимися
+// значениями всех полей сортировки. Размеры 9, 10 и 100 упорядочены
+// по-разному как числа и как строки.
+func seedPageImages(t *testing.T, db *sql.DB) (string, []pageTestImage) {
+	t.Helper()
+
+	user := &models.User{Username: "owner", PasswordHash: "x", Role: models.RoleUser}
+	if err := NewUserRepository(db).Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	images := []pageTestImage{
+		{"img-01", "b.png", 10, base},
+		{"img-02", "a.png", 9, base},
+		{"img-03", "b.png", 100, base.Add(500 * time.Millisecond)},
+		{"img-04", "c.png", 10, base.Add(500 * time.Millisecond)},
+		{"img-05", "a.png", 10, base.Add(time.Second)},
+		{"img-06", "B.png", 9, base.Add(1500 * time.Millisecond)},
+		{"img-07", "c.png", 100, base.Add(250 * time.Millisecond)},
+	}
+	for _, image := range images {
+		_, err := db.Exec(`
+			INSERT INTO images (id, user_id, original_name, file_name, storage_key, mime_type, size, created_at)
+			VALUES (?, ?, ?, ?, ?, 'image/png', ?, ?)
+		`, image.id, user.ID, image.name, image.id+".png", image.id+".png", image.size, image.createdAt)
+		if err != nil {
+			t.Fatalf("insert image %s: %v", image.id, err)
+		}
+	}
+	return user.ID, images
+}
+
+// expectedOrder сортирует изображения так, как их должна отдать база:
+// по полю сортировки, при равенстве — по id, в одном направлении
+func expectedOrder(images []pageTestImage, sort string, asc bool) []string {
+	sorted := slices.Clone(images)
+	slices.SortFunc(sorted, func(a, b pageTestImage) int {
+		var c int
+		switch sort {
+		case models.SortSize:
+			c = cmp.Compare(a.size, b.size)
+		case models.SortName:
+			c = cmp.Compare(a.name, b.name)
+		default:
+			c = a.createdAt.Compare(b.createdAt)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.id, b.id)
+		}
+		if !asc {
+			c = -c
+		}
+		return c
+	})
+
+	ids := make([]string, len(sorted))
+	for i, image := range sorted {
+		ids[i] = image.id
+	}
+	return ids
+}
+
+func TestImageListKeyset(t *testing.T) {
+	db := newTestDB(t)
+	userID, images := seedPageImages(t, db)
+	repo := NewImageRepository(db)
+
+	for _, sort := range []string{models.SortCreatedAt, models.SortSize, models.SortName} {
+		for _, asc := range []bool{false, true} {
+			for _, limit := range []int{1, 2, 3, len(images)} {
+				t.Run(fmt.Sprintf("%s/asc=%t/limit=%d", sort, asc, limit), func(t *testing.T) {
+					var got []string
+					page := models.PageRequest{Sort: sort, Asc: asc, Limit: limit}
+					for range len(images) + 1 {
+						result, err := repo.List(models.ImageFilter{UserID: userID}, page)
+						if err != nil {
+							t.Fatalf("list: %v", err)
+						}
+						if result.Total != len(images) {
+							t.Errorf("total = %d, want %d", result.Total, len(images))
+						}
+						if len(result.Items) > limit {
+							t.Fatalf("page has %d items, limit %d", len(result.Items), limit)
+						}
+						for _, image := range result.Items {
+							got = append(got, image.ID)
+						}
+						if result.NextCursor == "" {
+							break
+						}
+						page.Cursor = result.NextCursor
+					}
+
+					if want := expectedOrder(images, sort, asc); !slices.Equal(got, want) {
+						t.Errorf("got %v, want %v", got, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestImageListInvalidCursor(t *testing.T) {
+	db := newTestDB(t)
+	userID, _ := seedPageImages(t, db)
+	repo := NewImageRepository(db)
+	filter := models.ImageFilter{UserID: userID}
+
+	first, err := repo.List(filter, models.PageRequest{Sort: models.SortSize, Limit: 2})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if first.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	// Курсор от сортировки по размеру, переписанный под сортировку по имени
+	raw, _ := base64.RawURLEncoding.DecodeString(first.NextCursor)
+	var c cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	c.Sort = models.SortName
+	raw, _ = json.Marshal(c)
+	relabeled := base64.RawURLEncoding.EncodeToString(raw)
+
+	tests := []struct {
+		name string
+		page models.PageRequest
+	}{
+		{"other sort", models.PageRequest{Sort: models.SortName, Limit: 2, Cursor: first.NextCursor}},
+		{"other direction", models.PageRequest{Sort: models.SortSize, Asc: true, Limit: 2, Cursor: first.NextCursor}},
+		{"relabeled sort", models.PageRequest{Sort: models.SortSize, Limit: 2, Cursor: relabeled}},
+		{"not base64", models.PageRequest{Sort: models.SortSize, Limit: 2, Cursor: "not a cursor"}},
+		{"not json", models.PageRequest{Sort: models.SortSize, Limit: 2, Cursor: base64.RawURLEncoding.EncodeToString([]byte("size:10"))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := repo.List(filter, tt.page); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("err = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"image-uploader-backend/internal/models"
+)
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// QuotaRepository — использование хранилища и лимиты пользователей.
+// Использование ведут триггеры на images (см. миграцию 0017).
+type QuotaRepository struct {
+	db *sql.DB
+}
+
+func NewQuotaRepository(db *sql.DB) *QuotaRepository {
+	return &QuotaRepository{db: db}
+}
+
+// Get возвращает роль, использование и заданные администратором лимиты
+// пользователя; действующие лимиты не заполняются
+func (r *QuotaRepository) Get(userID string) (*models.UserQuota, error) {
+	quota := &models.UserQuota{UserID: userID}
+	var maxBytes, maxImages sql.NullInt64
+
+	err := r.db.QueryRow(`
+		SELECT u.role, COALESCE(q.bytes_used, 0), COALESCE(q.images_used, 0), q.max_bytes, q.max_images
+		FROM users u
+		LEFT JOIN user_quotas q ON q.user_id = u.id
+		WHERE u.id = ?
+	`, userID).Scan(&quota.Role, &quota.BytesUsed, &quota.ImagesUsed, &maxBytes, &maxImages)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if maxBytes.Valid {
+		quota.Override.MaxBytes = &maxBytes.Int64
+	}
+	if maxImages.Valid {
+		quota.Override.MaxImages = &maxImages.Int64
+	}
+	return quota, nil
+}
+
+// SetOverride задает лимиты пользователя; nil возвращает лимит по умолчанию
+func (r *QuotaRepository) SetOverride(userID string, override models.QuotaOverride) error {
+	_, err := r.db.Exec(`
+		INSERT INTO user_quotas (user_id, max_bytes, max_images) VALUES (?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET max_bytes = excluded.max_bytes, max_images = excluded.max_images
+	`, userID, override.MaxBytes, override.MaxImages)
+	return err
+}
+
+// checkQuota проверяет использование пользователя после изменения в tx.
+// Запись в транзакции SQLite исключительна, поэтому параллельные загрузки
+// не могут вместе превысить лимит.
+func checkQuota(tx *sql.Tx, userID string, limits models.QuotaLimits) error {
+	if limits.MaxBytes == 0 && limits.MaxImages == 0 {
+		return nil
+	}
+
+	var bytesUsed, imagesUsed int64
+	err := tx.QueryRow(`SELECT bytes_used, images_used FROM user_quotas WHERE user_id = ?`, userID).
+		Scan(&bytesUsed, &imagesUsed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if (limits.MaxBytes > 0 && bytesUsed > limits.MaxBytes) || (limits.MaxImages > 0 && imagesUsed > limits.MaxImages) {
+		return ErrQuotaExceeded
+	}
+	return nil
+}
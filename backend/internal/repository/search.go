@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"database/sql"
+	"image-uploader-backend/internal/models"
+)
+
+// Маркеры начала и конца совпадения в подсветке FTS5; заменяются на
+// разметку после экранирования текста
+const (
+	HighlightStart = "\x01"
+	HighlightEnd   = "\x02"
+)
+
+// Search ищет изображения по имени файла, подписи и тегам в порядке
+// релевантности (bm25; совпадение в имени весит больше, чем в подписи и тегах)
+func (r *ImageRepository) Search(search models.ImageSearch) ([]*models.ImageSearchResult, error) {
+	query := `
+		SELECT ` + imageColumns + `,
+			(SELECT username FROM users WHERE users.id = images.user_id),
+			m.rank, m.name_hl, m.caption_hl, m.tags_hl
+		FROM (
+			SELECT s.image_id,
+				bm25(images_fts, 10.0, 5.0, 3.0) AS rank,
+				highlight(images_fts, 0, char(1), char(2)) AS name_hl,
+				snippet(images_fts, 1, char(1), char(2), '…', 24) AS caption_hl,
+				highlight(images_fts, 2, char(1), char(2)) AS tags_hl
+			FROM images_fts
+			JOIN image_search_ids s ON s.id = images_fts.rowid
+			WHERE images_fts MATCH ?
+		) m
+		JOIN images ON images.id = m.image_id
+		WHERE 1 = 1`
+	args := []any{search.Query}
+
+	if search.UserID != "" {
+		query += ` AND images.user_id = ?`
+		args = append(args, search.UserID)
+	}
+	if !search.From.IsZero() {
+		query += ` AND images.created_at >= ?`
+		args = append(args, search.From.UTC())
+	}
+	if !search.To.IsZero() {
+		query += ` AND images.created_at < ?`
+		args = append(args, search.To.UTC())
+	}
+	query += ` ORDER BY m.rank, images.created_at DESC LIMIT ?`
+	args = append(args, search.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []*models.ImageSearchResult{}
+	for rows.Next() {
+		result := &models.ImageSearchResult{}
+		var username sql.NullString
+		var rank float64
+		result.Image, err = scanImage(rows, &username, &rank,
+			&result.Highlights.OriginalName, &result.Highlights.Caption, &result.Highlights.Tags)
+		if err != nil {
+			return nil, err
+		}
+		result.Username = username.String
+		// bm25 тем меньше, чем лучше совпадение
+		result.Score = -rank
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"image-uploader-backend/internal/models"
+	"sync"
+	"time"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore хранит сессии по хешу идентификатора из cookie
+type SessionStore interface {
+	Create(session *models.Session) error
+	Get(idHash string) (*models.Session, error)
+	Touch(idHash string, lastSeen time.Time) error
+	Delete(idHash string) error
+	DeleteByUserID(userID string) error
+	DeleteExpired(now time.Time) error
+}
+
+// MemorySessionStore хранит сессии в памяти процесса.
+// Сессии теряются при перезапуске, подходит для разработки и одной реплики.
+type MemorySessionStore struct {
+	sessions map[string]*models.Session
+	mu       sync.RWMutex
+}
+
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string]*models.Session),
+	}
+}
+
+func (s *MemorySessionStore) Create(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	copied := *session
+	s.sessions[session.IDHash] = &copied
+	return nil
+}
+
+func (s *MemorySessionStore) Get(idHash string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, exists := s.sessions[idHash]
+	if !exists {
+		return nil, ErrSessionNotFound
+	}
+
+	copied := *session
+	return &copied, nil
+}
+
+func (s *MemorySessionStore) Touch(idHash string, lastSeen time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, exists := s.sessions[idHash]; exists {
+		session.LastSeen = lastSeen
+	}
+	return nil
+}
+
+func (s *MemorySessionStore) Delete(idHash string) error {
+	s.mu.Lock()
+	delete(s.sessions, idHash)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemorySessionStore) DeleteByUserID(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idHash, session := range s.sessions {
+		if session.UserID == userID {
+			delete(s.sessions, idHash)
+		}
+	}
+	return nil
+}
+
+func (s *MemorySessionStore) DeleteExpired(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idHash, session := range s.sessions {
+		if now.After(session.ExpiresAt) {
+			delete(s.sessions, idHash)
+		}
+	}
+	return nil
+}
+
+// SQLiteSessionStore хранит сессии в таблице sessions, поэтому они
+// переживают перезапуск и общие для всех реплик с одной базой
+type SQLiteSessionStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteSessionStore(db *sql.DB) *SQLiteSessionStore {
+	return &SQLiteSessionStore{db: db}
+}
+
+func (s *SQLiteSessionStore) Create(session *models.Session) error {
+	query := `
+		INSERT INTO sessions (id_hash, user_id, expires_at, created_at, last_seen, user_agent, ip)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	// Время храним в UTC, чтобы строковое сравнение expires_at в SQL было корректным
+	_, err := s.db.Exec(query, session.IDHash, session.UserID, session.ExpiresAt.UTC(), session.CreatedAt.UTC(),
+		session.LastSeen.UTC(), session.UserAgent, session.IP)
+	return err
+}
+
+func (s *SQLiteSessionStore) Get(idHash string) (*models.Session, error) {
+	session := &models.Session{}
+	query := `SELECT id_hash, user_id, expires_at, created_at, last_seen, user_agent, ip
+	          FROM sessions WHERE id_hash = ?`
+
+	err := s.db.QueryRow(query, idHash).Scan(
+		&session.IDHash, &session.UserID, &session.ExpiresAt, &session.CreatedAt,
+		&session.LastSeen, &session.UserAgent, &session.IP,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (s *SQLiteSessionStore) Touch(idHash string, lastSeen time.Time) error {
+	_, err := s.db.Exec(`UPDATE sessions SET last_seen = ? WHERE id_hash = ?`, lastSeen.UTC(), idHash)
+	return err
+}
+
+func (s *SQLiteSessionStore) Delete(idHash string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE id_hash = ?`, idHash)
+	return err
+}
+
+func (s *SQLiteSessionStore) DeleteByUserID(userID string) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE user_id = ?`, userID)
+	return err
+}
+
+func (s *SQLiteSessionStore) DeleteExpired(now time.Time) error {
+	_, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at < ?`, now.UTC())
+	return err
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"image-uploader-backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrShareUnavailable — ссылка не найдена, отозвана, истекла или просмотры исчерпаны
+var ErrShareUnavailable = errors.New("share link is not available")
+
+type ShareRepository struct {
+	db *sql.DB
+}
+
+func NewShareRepository(db *sql.DB) *ShareRepository {
+	return &ShareRepository{db: db}
+}
+
+const shareColumns = `s.id, s.slug, s.user_id, s.password_hash, s.max_views, s.view_count, s.last_viewed_at, s.expires_at, s.created_at, s.revoked_at`
+
+// Create сохраняет ссылку вместе со списком изображений в одной транзакции
+func (r *ShareRepository) Create(link *models.ShareLink) error {
+	link.ID = uuid.New().String()
+	link.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO share_links (id, slug, user_id, password_hash, max_views, view_count, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?)
+	`
+
+	// expires_at сравнивается в SQL, поэтому храним его в UTC
+	var expiresAt any
+	if link.ExpiresAt != nil {
+		expiresAt = link.ExpiresAt.UTC()
+	}
+
+	_, err = tx.Exec(query, link.ID, link.Slug, link.UserID, link.PasswordHash, link.MaxViews, expiresAt, link.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i, imageID := range link.ImageIDs {
+		_, err := tx.Exec(`INSERT INTO share_link_images (share_id, image_id, position) VALUES (?, ?, ?)`,
+			link.ID, imageID, i)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetBySlug возвращает ссылку с изображениями. Возвращает sql.ErrNoRows, если ее нет.
+func (r *ShareRepository) GetBySlug(slug string) (*models.ShareLink, error) {
+	link, err := scanShareLink(r.db.QueryRow(`SELECT `+shareColumns+` FROM share_links s WHERE s.slug = ?`, slug))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachImages([]*models.ShareLink{link}); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// GetByUserID возвращает ссылки пользователя, новые первыми
+func (r *ShareRepository) GetByUserID(userID string) ([]*models.ShareLink, error) {
+	return r.list(`SELECT `+shareColumns+`, '' FROM share_links s WHERE s.user_id = ? ORDER BY s.created_at DESC`, userID)
+}
+
+// GetAll возвращает ссылки всех пользователей с именами владельцев
+func (r *ShareRepository) GetAll() ([]*models.ShareLink, error) {
+	return r.list(`
+		SELECT ` + shareColumns + `, u.username
+		FROM share_links s
+		JOIN users u ON u.id = s.user_id
+		ORDER BY s.created_at DESC
+	`)
+}
+
+func (r *ShareRepository) list(query string, args ...any) ([]*models.ShareLink, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*models.ShareLink
+	for rows.Next() {
+		var username string
+		link, err := scanShareLink(rows, &username)
+		if err != nil {
+			return nil, err
+		}
+		link.Username = username
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachImages(links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// attachImages заполняет ImageIDs ссылок в порядке показа
+func (r *ShareRepository) attachImages(links []*models.ShareLink) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*models.ShareLink, len(links))
+	args := make([]any, len(links))
+	for i, link := range links {
+		link.ImageIDs = []string{}
+		byID[link.ID] = link
+		args[i] = link.ID
+	}
+
+	query := `
+		SELECT share_id, image_id
+		FROM share_link_images
+		WHERE share_id IN (?` + strings.Repeat(",?", len(links)-1) + `)
+		ORDER BY share_id, position
+	`
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var shareID, imageID string
+		if err := rows.Scan(&shareID, &imageID); err != nil {
+			return err
+		}
+		byID[shareID].ImageIDs = append(byID[shareID].ImageIDs, imageID)
+	}
+
+	return rows.Err()
+}
+
+// Revoke отзывает ссылку. Пустой userID — любую ссылку (администратор).
+// Возвращает sql.ErrNoRows, если активной ссылки нет.
+func (r *ShareRepository) Revoke(id, userID string) error {
+	query := `UPDATE share_links SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`
+	args := []any{time.Now(), id}
+	if userID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, userID)
+	}
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordView засчитывает просмотр, если ссылка еще действует, и сохраняет
+// токен просмотра со сроком filesExpireAt. Проверка и увеличение счетчика
+// выполняются одним запросом, поэтому параллельные просмотры не превысят
+// max_views. Возвращает ErrShareUnavailable, если ссылка уже не действует.
+func (r *ShareRepository) RecordView(id, token string, now, filesExpireAt time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var viewCount int
+	query := `
+		UPDATE share_links SET view_count = view_count + 1, last_viewed_at = ?
+		WHERE id = ?
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > ?)
+		  AND (max_views = 0 OR view_count < max_views)
+		RETURNING view_count
+	`
+	err = tx.QueryRow(query, now, id, now.UTC()).Scan(&viewCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrShareUnavailable
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Просроченные токены этой ссылки больше не нужны
+	if _, err := tx.Exec(`DELETE FROM share_link_views WHERE share_id = ? AND expires_at <= ?`, id, now.UTC()); err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`INSERT INTO share_link_views (token, share_id, viewed_at, expires_at) VALUES (?, ?, ?, ?)`,
+		token, id, now, filesExpireAt.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return viewCount, tx.Commit()
+}
+
+// ValidView сообщает, открывает ли токен просмотра файлы ссылки в момент now
+func (r *ShareRepository) ValidView(shareID, token string, now time.Time) (bool, error) {
+	var n int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM share_link_views WHERE token = ? AND share_id = ? AND expires_at > ?`,
+		token, shareID, now.UTC()).Scan(&n)
+	return n > 0, err
+}
+
+// scanShareLink читает строку shareColumns и дополнительные столбцы extra
+func scanShareLink(row rowScanner, extra ...any) (*models.ShareLink, error) {
+	link := &models.ShareLink{}
+	var lastViewedAt, expiresAt, revokedAt sql.NullTime
+
+	dest := []any{
+		&link.ID, &link.Slug, &link.UserID, &link.PasswordHash, &link.MaxViews, &link.ViewCount,
+		&lastViewedAt, &expiresAt, &link.CreatedAt, &revokedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	link.HasPassword = link.PasswordHash != ""
+	link.LastViewedAt = nullTimePtr(lastViewedAt)
+	link.ExpiresAt = nullTimePtr(expiresAt)
+	link.RevokedAt = nullTimePtr(revokedAt)
+
+	return link, nil
+}
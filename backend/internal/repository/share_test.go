@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"image-uploader-backend/internal/models"
+)
+
+// newTestShareLink создает пользователя и ссылку без изображений
+func newTestShareLink(t *testing.T, db *sql.DB, maxViews int, expiresAt *time.Time) *models.ShareLink {
+	t.Helper()
+
+	user := &models.User{Username: fmt.Sprintf("owner-%d", time.Now().UnixNano()), PasswordHash: "x", Role: models.RoleUser}
+	if err := NewUserRepository(db).Create(user); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	link := &models.ShareLink{Slug: "slug-" + user.ID, UserID: user.ID, MaxViews: maxViews, ExpiresAt: expiresAt}
+	if err := NewShareRepository(db).Create(link); err != nil {
+		t.Fatalf("create share link: %v", err)
+	}
+	return link
+}
+
+func TestShareRecordViewMaxViewsRace(t *testing.T) {
+	const maxViews, viewers = 5, 32
+
+	db := newTestFileDB(t)
+	link := newTestShareLink(t, db, maxViews, nil)
+	repo := NewShareRepository(db)
+
+	now := time.Now()
+	start := make(chan struct{})
+	counts := make(chan int, viewers)
+	errs := make(chan error, viewers)
+
+	var wg sync.WaitGroup
+	for i := range viewers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			count, err := repo.RecordView(link.ID, fmt.Sprintf("token-%d", i), now, now.Add(time.Hour))
+			if err != nil {
+				errs <- err
+				return
+			}
+			counts <- count
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(counts)
+	close(errs)
+
+	for err := range errs {
+		if !errors.Is(err, ErrShareUnavailable) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	// Каждый засчитанный просмотр получает свой номер от 1 до maxViews
+	seen := make(map[int]bool)
+	for count := range counts {
+		if count < 1 || count > maxViews || seen[count] {
+			t.Errorf("unexpected view count %d", count)
+		}
+		seen[count] = true
+	}
+	if len(seen) != maxViews {
+		t.Errorf("%d views recorded, want %d", len(seen), maxViews)
+	}
+
+	stored, err := repo.GetBySlug(link.Slug)
+	if err != nil {
+		t.Fatalf("get link: %v", err)
+	}
+	if stored.ViewCount != maxViews {
+		t.Errorf("view_count = %d, want %d", stored.ViewCount, maxViews)
+	}
+
+	var tokens int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM share_link_views WHERE share_id = ?`, link.ID).Scan(&tokens); err != nil {
+		t.Fatalf("count views: %v", err)
+	}
+	if tokens != maxViews {
+		t.Errorf("%d view tokens stored, want %d", tokens, maxViews)
+	}
+}
+
+func TestShareRecordView(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name     string
+		maxViews int
+		expires  *time.Time
+		revoked  bool
+		views    int // Сколько просмотров подряд
+		want     []error
+	}{
+		{"unlimited", 0, nil, false, 3, []error{nil, nil, nil}},
+		{"max views", 2, nil, false, 3, []error{nil, nil, ErrShareUnavailable}},
+		{"not expired", 0, &future, false, 1, []error{nil}},
+		{"expired", 0, &past, false, 1, []error{ErrShareUnavailable}},
+		{"expires now", 0, &now, false, 1, []error{ErrShareUnavailable}},
+		{"revoked", 0, nil, true, 1, []error{ErrShareUnavailable}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDB(t)
+			link := newTestShareLink(t, db, tt.maxViews, tt.expires)
+			repo := NewShareRepository(db)
+			if tt.revoked {
+				if err := repo.Revoke(link.ID, ""); err != nil {
+					t.Fatalf("revoke: %v", err)
+				}
+			}
+
+			for i := range tt.views {
+				token := fmt.Sprintf("token-%d", i)
+				count, err := repo.RecordView(link.ID, token, now, now.Add(time.Hour))
+				if !errors.Is(err, tt.want[i]) {
+					t.Fatalf("view %d: err = %v, want %v", i+1, err, tt.want[i])
+				}
+				if err != nil {
+					continue
+				}
+				if count != i+1 {
+					t.Errorf("view %d: count = %d", i+1, count)
+				}
+				if ok, err := repo.ValidView(link.ID, token, now); err != nil || !ok {
+					t.Errorf("view %d: token not valid (err %v)", i+1, err)
+				}
+			}
+		})
+	}
+}
+
+func TestShareRecordViewExpiresTokens(t *testing.T) {
+	db := newTestDB(t)
+	link := newTestShareLink(t, db, 0, nil)
+	repo := NewShareRepository(db)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if _, err := repo.RecordView(link.ID, "first", now, now.Add(time.Minute)); err != nil {
+		t.Fatalf("first view: %v", err)
+	}
+	if ok, _ := repo.ValidView(link.ID, "first", now.Add(time.Minute)); ok {
+		t.Error("token is valid after its expiry")
+	}
+
+	// Следующий просмотр удаляет просроченный токен
+	later := now.Add(2 * time.Minute)
+	if _, err := repo.RecordView(link.ID, "second", later, later.Add(time.Minute)); err != nil {
+		t.Fatalf("second view: %v", err)
+	}
+	var tokens int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM share_link_views WHERE share_id = ?`, link.ID).Scan(&tokens); err != nil {
+		t.Fatalf("count views: %v", err)
+	}
+	if tokens != 1 {
+		t.Errorf("%d view tokens stored, want 1", tokens)
+	}
+}
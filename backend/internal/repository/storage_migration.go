@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"database/sql"
+	"image-uploader-backend/internal/models"
+	"strings"
+	"time"
+)
+
+// StorageMigrationRepository перечисляет объекты, на которые ссылается база,
+// и хранит прогресс их переноса в другое хранилище
+type StorageMigrationRepository struct {
+	db *sql.DB
+}
+
+func NewStorageMigrationRepository(db *sql.DB) *StorageMigrationRepository {
+	return &StorageMigrationRepository{db: db}
+}
+
+// ListObjects возвращает до limit объектов с ключом больше afterKey в порядке
+// ключей. Один файл может принадлежать нескольким изображениям, но
+// возвращается один раз.
+func (r *StorageMigrationRepository) ListObjects(afterKey string, limit int) ([]models.StoredObject, error) {
+	query := `
+		SELECT storage_key, MAX(size), MAX(sha256)
+		FROM (
+			SELECT storage_key, size, sha256 FROM images
+			UNION ALL
+			SELECT storage_key, size, '' FROM image_variants
+		)
+		WHERE storage_key > ?
+		GROUP BY storage_key
+		ORDER BY storage_key
+		LIMIT ?
+	`
+
+	rows, err := r.db.Query(query, afterKey, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var objects []models.StoredObject
+	for rows.Next() {
+		var obj models.StoredObject
+		if err := rows.Scan(&obj.Key, &obj.Size, &obj.SHA256); err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	return objects, rows.Err()
+}
+
+// CopiedKeys возвращает ключи из набора, уже перенесенные в target
+func (r *StorageMigrationRepository) CopiedKeys(target string, keys []string) (map[string]bool, error) {
+	result := make(map[string]bool)
+	if len(keys) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(keys)), ",")
+	args := make([]any, 0, len(keys)+1)
+	args = append(args, target)
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	query := `SELECT storage_key FROM storage_migration_progress WHERE target = ? AND storage_key IN (` + placeholders + `)`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		result[key] = true
+	}
+
+	return result, rows.Err()
+}
+
+// MarkCopied записывает пачку перенесенных объектов одной транзакцией
+func (r *StorageMigrationRepository) MarkCopied(target string, objects []models.StoredObject) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, obj := range objects {
+		query := `INSERT OR REPLACE INTO storage_migration_progress (target, storage_key, size, copied_at) VALUES (?, ?, ?, ?)`
+		if _, err := tx.Exec(query, target, obj.Key, obj.Size, now); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"image-uploader-backend/internal/models"
+	"strings"
+)
+
+func insertTags(ex execer, imageID string, tags []string) error {
+	for _, tag := range tags {
+		_, err := ex.Exec(`INSERT OR IGNORE INTO image_tags (image_id, tag) VALUES (?, ?)`, imageID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetTags заменяет все теги изображения
+func (r *ImageRepository) SetTags(imageID string, tags []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM image_tags WHERE image_id = ?`, imageID); err != nil {
+		return err
+	}
+	if err := insertTags(tx, imageID, tags); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// AddTags добавляет теги к изображению; уже имеющиеся пропускаются
+func (r *ImageRepository) AddTags(imageID string, tags []string) error {
+	return insertTags(r.db, imageID, tags)
+}
+
+// GetTags возвращает теги для набора изображений по image_id, по алфавиту
+func (r *ImageRepository) GetTags(imageIDs []string) (map[string][]string, error) {
+	result := make(map[string][]string)
+	if len(imageIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(imageIDs)), ",")
+	args := make([]any, len(imageIDs))
+	for i, id := range imageIDs {
+		args[i] = id
+	}
+
+	rows, err := r.db.Query(`SELECT image_id, tag FROM image_tags WHERE image_id IN (`+placeholders+`) ORDER BY tag`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var imageID, tag string
+		if err := rows.Scan(&imageID, &tag); err != nil {
+			return nil, err
+		}
+		result[imageID] = append(result[imageID], tag)
+	}
+
+	return result, rows.Err()
+}
+
+// TagCounts возвращает теги изображений пользователя с числом изображений,
+// начиная с самых частых. prefix отбирает теги, начинающиеся с него;
+// limit 0 — без ограничения.
+func (r *ImageRepository) TagCounts(userID, prefix string, limit int) ([]*models.TagCount, error) {
+	query := `
+		SELECT t.tag, COUNT(*)
+		FROM image_tags t
+		JOIN images i ON i.id = t.image_id
+		WHERE i.user_id = ?`
+	args := []any{userID}
+	if prefix != "" {
+		query += ` AND t.tag LIKE ? ESCAPE '\'`
+		args = append(args, escapeLike(prefix)+"%")
+	}
+	query += ` GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []*models.TagCount{}
+	for rows.Next() {
+		count := &models.TagCount{}
+		if err := rows.Scan(&count.Tag, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// tagCondition возвращает условие отбора изображений по тегам фильтра
+// (пустое, если теги не заданы) и его аргументы
+func tagCondition(filter models.ImageFilter) (string, []any) {
+	if len(filter.Tags) == 0 {
+		return "", nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(filter.Tags)), ",")
+	args := make([]any, len(filter.Tags))
+	for i, tag := range filter.Tags {
+		args[i] = tag
+	}
+
+	cond := `images.id IN (SELECT image_id FROM image_tags WHERE tag IN (` + placeholders + `)`
+	if filter.AllTags {
+		// Теги в фильтре без повторов, поэтому совпадение числа означает все теги
+		cond += ` GROUP BY image_id HAVING COUNT(*) = ?`
+		args = append(args, len(filter.Tags))
+	}
+	return cond + `)`, args
+}
+
+// escapeLike экранирует спецсимволы LIKE для ESCAPE '\'
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"database/sql"
+	"image-uploader-backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TokenRepository struct {
+	db *sql.DB
+}
+
+func NewTokenRepository(db *sql.DB) *TokenRepository {
+	return &TokenRepository{db: db}
+}
+
+const tokenColumns = `id, user_id, name, token_hash, prefix, scopes, created_at, last_used_at, expires_at, revoked_at`
+
+func (r *TokenRepository) Create(token *models.APIToken) error {
+	token.ID = uuid.New().String()
+	token.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO api_tokens (id, user_id, name, token_hash, prefix, scopes, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := r.db.Exec(query, token.ID, token.UserID, token.Name, token.TokenHash, token.Prefix,
+		strings.Join(token.Scopes, ","), token.CreatedAt, token.ExpiresAt)
+	return err
+}
+
+func (r *TokenRepository) GetByHash(tokenHash string) (*models.APIToken, error) {
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE token_hash = ?`
+	return scanToken(r.db.QueryRow(query, tokenHash))
+}
+
+func (r *TokenRepository) GetByUserID(userID string) ([]*models.APIToken, error) {
+	query := `SELECT ` + tokenColumns + ` FROM api_tokens WHERE user_id = ? ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []*models.APIToken
+	for rows.Next() {
+		token, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// Revoke отзывает токен пользователя. Возвращает sql.ErrNoRows, если
+// активного токена с таким id у пользователя нет.
+func (r *TokenRepository) Revoke(id, userID string) error {
+	result, err := r.db.Exec(`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *TokenRepository) UpdateLastUsed(id string, lastUsed time.Time) error {
+	_, err := r.db.Exec(`UPDATE api_tokens SET last_used_at = ? WHERE id = ?`, lastUsed, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanToken(row rowScanner) (*models.APIToken, error) {
+	token := &models.APIToken{}
+	var scopes string
+	var lastUsedAt, expiresAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.Prefix, &scopes,
+		&token.CreatedAt, &lastUsedAt, &expiresAt, &revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if scopes != "" {
+		token.Scopes = strings.Split(scopes, ",")
+	}
+	token.LastUsedAt = nullTimePtr(lastUsedAt)
+	token.ExpiresAt = nullTimePtr(expiresAt)
+	token.RevokedAt = nullTimePtr(revokedAt)
+
+	return token, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
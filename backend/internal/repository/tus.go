@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"image-uploader-backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TusRepository struct {
+	db *sql.DB
+}
+
+func NewTusRepository(db *sql.DB) *TusRepository {
+	return &TusRepository{db: db}
+}
+
+const tusColumns = `id, user_id, length, upload_offset, filename, filetype, visibility, album_id, tags, caption, image_id, created_at, expires_at`
+
+func (r *TusRepository) Create(upload *models.TusUpload) error {
+	upload.ID = uuid.New().String()
+	upload.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO tus_uploads (id, user_id, length, upload_offset, filename, filetype, visibility, album_id, tags, caption, created_at, expires_at)
+		VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	// expires_at сравнивается в SQL, поэтому храним его в UTC
+	_, err := r.db.Exec(query, upload.ID, upload.UserID, upload.Length, upload.Filename, upload.Filetype,
+		upload.Visibility, upload.AlbumID, strings.Join(upload.Tags, ","), upload.Caption, upload.CreatedAt, upload.ExpiresAt.UTC())
+	return err
+}
+
+// GetByID возвращает загрузку. Возвращает sql.ErrNoRows, если ее нет.
+func (r *TusRepository) GetByID(id string) (*models.TusUpload, error) {
+	upload := &models.TusUpload{}
+	var imageID sql.NullString
+	var tags string
+
+	err := r.db.QueryRow(`SELECT `+tusColumns+` FROM tus_uploads WHERE id = ?`, id).Scan(
+		&upload.ID, &upload.UserID, &upload.Length, &upload.Offset, &upload.Filename, &upload.Filetype,
+		&upload.Visibility, &upload.AlbumID, &tags, &upload.Caption, &imageID, &upload.CreatedAt, &upload.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	upload.ImageID = imageID.String
+	if tags != "" {
+		upload.Tags = strings.Split(tags, ",")
+	}
+	return upload, nil
+}
+
+// UpdateOffset сохраняет новое смещение и продлевает срок жизни загрузки
+func (r *TusRepository) UpdateOffset(id string, offset int64, expiresAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE tus_uploads SET upload_offset = ?, expires_at = ? WHERE id = ?`,
+		offset, expiresAt.UTC(), id)
+	return err
+}
+
+// Complete связывает собранную загрузку с созданным изображением
+func (r *TusRepository) Complete(id, imageID string) error {
+	_, err := r.db.Exec(`UPDATE tus_uploads SET image_id = ? WHERE id = ?`, imageID, id)
+	return err
+}
+
+func (r *TusRepository) Delete(id string) error {
+	_, err := r.db.Exec(`DELETE FROM tus_uploads WHERE id = ?`, id)
+	return err
+}
+
+// DeleteByUserID удаляет все загрузки пользователя и возвращает их id
+func (r *TusRepository) DeleteByUserID(userID string) ([]string, error) {
+	return deleteUploads(r.db, `DELETE FROM tus_uploads WHERE user_id = ? RETURNING id`, userID)
+}
+
+// DeleteExpired удаляет просроченные загрузки и возвращает их id,
+// чтобы вызывающий мог удалить данные с диска
+func (r *TusRepository) DeleteExpired(now time.Time) ([]string, error) {
+	return deleteUploads(r.db, `DELETE FROM tus_uploads WHERE expires_at < ? RETURNING id`, now.UTC())
+}
+
+// deleteUploads выполняет DELETE … RETURNING id и собирает id удаленных загрузок
+func deleteUploads(db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -2,12 +2,19 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"image-uploader-backend/internal/models"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
+var ErrUserHasImages = errors.New("user has images: set images to delete or reassign")
+
 type UserRepository struct {
 	db *sql.DB
 }
@@ -16,28 +23,44 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// execer — общее подмножество *sql.DB и *sql.Tx для запросов,
+// которые выполняются как отдельно, так и внутри транзакции
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
 func (r *UserRepository) Create(user *models.User) error {
+	return insertUser(r.db, user)
+}
+
+func insertUser(ex execer, user *models.User) error {
 	user.ID = uuid.New().String()
 	user.CreatedAt = time.Now()
 
 	query := `
-		INSERT INTO users (id, username, password_hash, role, created_at)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO users (id, username, password_hash, role, created_at, password_reset_required)
+		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := r.db.Exec(query, user.ID, user.Username, user.PasswordHash, user.Role, user.CreatedAt)
+	_, err := ex.Exec(query, user.ID, user.Username, user.PasswordHash, user.Role, user.CreatedAt, user.PasswordResetRequired)
 	return err
 }
 
+const userColumns = `u.id, u.username, u.password_hash, u.role, u.created_at, u.disabled_at, u.password_reset_required`
+
 func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
-	user := &models.User{}
-	query := `SELECT id, username, password_hash, role, created_at 
-	          FROM users WHERE username = ?`
+	return r.getUser(`SELECT `+userColumns+` FROM users u WHERE u.username = ?`, username)
+}
 
-	err := r.db.QueryRow(query, username).Scan(
-		&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt,
-	)
+func (r *UserRepository) GetByID(id string) (*models.User, error) {
+	return r.getUser(`SELECT `+userColumns+` FROM users u WHERE u.id = ?`, id)
+}
 
+func (r *UserRepository) getUser(query string, args ...any) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRow(query, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -45,55 +68,182 @@ func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
 	return user, nil
 }
 
-func (r *UserRepository) GetByID(id string) (*models.User, error) {
-	user := &models.User{}
-	query := `SELECT id, username, password_hash, role, created_at 
-	          FROM users WHERE id = ?`
+// UpdateRole меняет роль пользователя
+func (r *UserRepository) UpdateRole(id, role string) error {
+	result, err := r.db.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrUserNotFound)
+}
 
-	err := r.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt,
-	)
+// SetDisabled отключает учетную запись с момента disabledAt; nil включает ее
+func (r *UserRepository) SetDisabled(id string, disabledAt *time.Time) error {
+	result, err := r.db.Exec(`UPDATE users SET disabled_at = ? WHERE id = ?`, disabledAt, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrUserNotFound)
+}
+
+// SetPassword сохраняет новый хеш пароля и признак обязательной смены пароля
+func (r *UserRepository) SetPassword(id, passwordHash string, resetRequired bool) error {
+	result, err := r.db.Exec(`UPDATE users SET password_hash = ?, password_reset_required = ? WHERE id = ?`,
+		passwordHash, resetRequired, id)
+	if err != nil {
+		return err
+	}
+	return requireAffected(result, ErrUserNotFound)
+}
+
+// ResetPassword в одной транзакции сохраняет временный пароль с признаком
+// обязательной смены и отзывает все API токены пользователя
+func (r *UserRepository) ResetPassword(id, passwordHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET password_hash = ?, password_reset_required = 1 WHERE id = ?`,
+		passwordHash, id)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, ErrUserNotFound); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE api_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`,
+		time.Now(), id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
 
+// Delete удаляет пользователя вместе с сессиями, токенами, альбомами и
+// ссылками. Если задан reassignTo, его изображения в той же транзакции
+// передаются этому пользователю; иначе изображений у пользователя быть
+// не должно — если они есть, возвращается ErrUserHasImages.
+func (r *UserRepository) Delete(id, reassignTo string) error {
+	tx, err := r.db.Begin()
 	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if reassignTo != "" {
+		if _, err := tx.Exec(`UPDATE images SET user_id = ? WHERE user_id = ?`, reassignTo, id); err != nil {
+			return err
+		}
+	} else {
+		var hasImages bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM images WHERE user_id = ?)`, id).Scan(&hasImages); err != nil {
+			return err
+		}
+		if hasImages {
+			return ErrUserHasImages
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if err := requireAffected(result, ErrUserNotFound); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func scanUser(row rowScanner, extra ...any) (*models.User, error) {
+	user := &models.User{}
+	var disabledAt sql.NullTime
+
+	dest := []any{
+		&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt, &disabledAt, &user.PasswordResetRequired,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
 		return nil, err
 	}
 
+	user.DisabledAt = nullTimePtr(disabledAt)
 	return user, nil
 }
 
-func (r *UserRepository) GetAllWithImageCount() ([]*models.UserWithImageCount, error) {
+// userSorts — выражения сортировки списка пользователей
+var userSorts = map[string]string{
+	models.SortCreatedAt: "u.created_at",
+	models.SortName:      "u.username",
+}
+
+// ListWithImageCount возвращает страницу пользователей с числом изображений
+// и общее число пользователей, подходящих под filter
+func (r *UserRepository) ListWithImageCount(filter models.UserFilter, page models.PageRequest) (*models.Page[*models.UserWithImageCount], error) {
+	sortExpr, ok := userSorts[page.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort %q", page.Sort)
+	}
+	k := keyset{sortExpr: sortExpr, idExpr: "u.id", page: page}
+
+	where := []string{"1 = 1"}
+	var args []any
+	if filter.Query != "" {
+		where = append(where, `u.username LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.Query)+"%")
+	}
+
+	result := &models.Page[*models.UserWithImageCount]{}
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM users u WHERE `+strings.Join(where, " AND "), args...).Scan(&result.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	after, afterArgs, err := k.after()
+	if err != nil {
+		return nil, err
+	}
+	if after != "" {
+		where = append(where, after)
+		args = append(args, afterArgs...)
+	}
+	orderBy, orderArgs := k.orderBy()
+
 	query := `
-		SELECT 
-			u.id, 
-			u.username, 
-			u.password_hash, 
-			u.role, 
-			u.created_at,
-			COUNT(i.id) as image_count
+		SELECT
+			` + userColumns + `,
+			(SELECT COUNT(*) FROM images i WHERE i.user_id = u.id) AS image_count,
+			` + k.column() + `
 		FROM users u
-		LEFT JOIN images i ON u.id = i.user_id
-		GROUP BY u.id, u.username, u.password_hash, u.role, u.created_at
-		ORDER BY u.created_at DESC
-	`
+		WHERE ` + strings.Join(where, " AND ") + orderBy
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, append(args, orderArgs...)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var users []*models.UserWithImageCount
+	var values, ids []string
+	result.Items = []*models.UserWithImageCount{}
 	for rows.Next() {
 		user := &models.UserWithImageCount{}
-		err := rows.Scan(
-			&user.ID, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.ImageCount,
-		)
+		var value string
+		u, err := scanUser(rows, &user.ImageCount, &value)
 		if err != nil {
 			return nil, err
 		}
-		users = append(users, user)
+		user.User = *u
+		result.Items = append(result.Items, user)
+		values = append(values, value)
+		ids = append(ids, user.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return users, nil
+	result.Items, result.NextCursor = trim(k, result.Items, values, ids)
+	return result, nil
 }
 
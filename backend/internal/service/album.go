@@ -0,0 +1,287 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	maxAlbumName        = 200
+	maxAlbumDescription = 2000
+	maxAlbumBatch       = 500
+)
+
+var (
+	ErrAlbumNotFound     = repository.ErrAlbumNotFound
+	ErrNotInAlbum        = repository.ErrNotInAlbum
+	ErrInvalidAlbumOrder = errors.New("image_ids must list every image of the album exactly once")
+	ErrInvalidAlbum      = errors.New("invalid album")
+)
+
+// AlbumService управляет альбомами. Видимость альбома определяет, кому
+// виден его состав; приватные изображения видны в альбоме только владельцу
+// и администратору, так что альбом не открывает доступ к файлам.
+type AlbumService struct {
+	repo   *repository.AlbumRepository
+	images *ImageService
+	config *config.Config
+}
+
+func NewAlbumService(repo *repository.AlbumRepository, images *ImageService, cfg *config.Config) *AlbumService {
+	return &AlbumService{
+		repo:   repo,
+		images: images,
+		config: cfg,
+	}
+}
+
+// Create создает пустой альбом пользователя
+func (s *AlbumService) Create(userID string, req models.CreateAlbumRequest) (*models.Album, error) {
+	name, err := albumName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.Description) > maxAlbumDescription {
+		return nil, fmt.Errorf("%w: description must be at most %d characters", ErrInvalidAlbum, maxAlbumDescription)
+	}
+	visibility, err := s.images.ResolveVisibility(req.Visibility)
+	if err != nil {
+		return nil, err
+	}
+	token, err := newAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	album := &models.Album{
+		UserID:      userID,
+		Name:        name,
+		Description: req.Description,
+		Visibility:  visibility,
+		AccessToken: token,
+	}
+	if err := s.repo.Create(album); err != nil {
+		return nil, err
+	}
+
+	album.URL = s.albumURL(album)
+	return album, nil
+}
+
+// ListByUser возвращает альбомы пользователя с обложками
+func (s *AlbumService) ListByUser(userID string) ([]*models.Album, error) {
+	albums, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, album := range albums {
+		album.URL = s.albumURL(album)
+		if err := s.attachCover(album, true); err != nil {
+			return nil, err
+		}
+	}
+	return albums, nil
+}
+
+// GetOwn возвращает альбом, принадлежащий пользователю. Чужой альбом
+// выглядит так же, как несуществующий.
+func (s *AlbumService) GetOwn(id, userID string) (*models.Album, error) {
+	album, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if album.UserID != userID {
+		return nil, ErrAlbumNotFound
+	}
+	return album, nil
+}
+
+// View возвращает альбом с изображениями, если зритель (nil — анонимный)
+// может его видеть: публичный — любой, unlisted — по токену из ссылки,
+// приватный — владелец и администратор. Остальным приватные и скрытые
+// изображения альбома не показываются.
+func (s *AlbumService) View(id string, viewer *models.User, token string) (*models.Album, error) {
+	album, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	privileged := viewer != nil && (viewer.ID == album.UserID || viewer.Role == models.RoleAdmin)
+	switch {
+	case privileged, album.Visibility == models.VisibilityPublic:
+	case album.Visibility == models.VisibilityUnlisted && token != "" && token == album.AccessToken:
+	default:
+		return nil, ErrAlbumNotFound
+	}
+
+	images, err := s.images.ListByAlbum(album.ID)
+	if err != nil {
+		return nil, err
+	}
+	album.Images = []*models.Image{}
+	for _, image := range images {
+		if privileged || (image.Visibility != models.VisibilityPrivate && image.HiddenAt == nil) {
+			album.Images = append(album.Images, image)
+		}
+	}
+	album.ImageCount = len(album.Images)
+
+	album.URL = s.albumURL(album)
+	if err := s.attachCover(album, privileged); err != nil {
+		return nil, err
+	}
+	return album, nil
+}
+
+// Update меняет название, описание, видимость или обложку альбома.
+// Обложкой может быть только изображение альбома. При переводе в unlisted
+// создается новый токен, так что ссылки, выданные раньше, перестают работать.
+func (s *AlbumService) Update(album *models.Album, req models.UpdateAlbumRequest) error {
+	if req.Name != nil {
+		name, err := albumName(*req.Name)
+		if err != nil {
+			return err
+		}
+		album.Name = name
+	}
+	if req.Description != nil {
+		if len(*req.Description) > maxAlbumDescription {
+			return fmt.Errorf("%w: description must be at most %d characters", ErrInvalidAlbum, maxAlbumDescription)
+		}
+		album.Description = *req.Description
+	}
+	if req.Visibility != nil {
+		if !models.ValidVisibility(*req.Visibility) {
+			return ErrInvalidVisibility
+		}
+		if *req.Visibility == models.VisibilityUnlisted && album.Visibility != models.VisibilityUnlisted {
+			token, err := newAccessToken()
+			if err != nil {
+				return err
+			}
+			album.AccessToken = token
+		}
+		album.Visibility = *req.Visibility
+	}
+	if req.CoverImageID != nil {
+		if *req.CoverImageID != "" {
+			ids, err := s.repo.ImageIDs(album.ID)
+			if err != nil {
+				return err
+			}
+			if !slices.Contains(ids, *req.CoverImageID) {
+				return ErrNotInAlbum
+			}
+		}
+		album.CoverImageID = *req.CoverImageID
+	}
+
+	return s.repo.Update(album)
+}
+
+// Delete удаляет альбом; изображения остаются
+func (s *AlbumService) Delete(album *models.Album) error {
+	return s.repo.Delete(album.ID)
+}
+
+// AddImages добавляет изображения пользователя в конец альбома. Чужие
+// и несуществующие изображения дают ErrImageNotFound.
+func (s *AlbumService) AddImages(album *models.Album, imageIDs []string) error {
+	if len(imageIDs) == 0 || len(imageIDs) > maxAlbumBatch {
+		return fmt.Errorf("%w: image_ids must contain between 1 and %d images", ErrInvalidAlbum, maxAlbumBatch)
+	}
+
+	for _, id := range imageIDs {
+		image, err := s.images.Lookup(id)
+		if err != nil {
+			return err
+		}
+		if image.UserID != album.UserID {
+			return ErrImageNotFound
+		}
+	}
+
+	return s.repo.AddImages(album.ID, imageIDs)
+}
+
+// RemoveImage убирает изображение из альбома
+func (s *AlbumService) RemoveImage(album *models.Album, imageID string) error {
+	return s.repo.RemoveImage(album.ID, imageID)
+}
+
+// Reorder задает новый порядок: imageIDs — все изображения альбома по одному разу
+func (s *AlbumService) Reorder(album *models.Album, imageIDs []string) error {
+	seen := make(map[string]bool, len(imageIDs))
+	for _, id := range imageIDs {
+		if seen[id] {
+			return ErrInvalidAlbumOrder
+		}
+		seen[id] = true
+	}
+
+	err := s.repo.Reorder(album.ID, imageIDs)
+	if errors.Is(err, repository.ErrAlbumOrderChange) {
+		return ErrInvalidAlbumOrder
+	}
+	return err
+}
+
+// attachCover заполняет Cover: выбранное изображение или первое в альбоме.
+// Если privileged не задан, приватное или скрытое изображение обложкой не показывается.
+func (s *AlbumService) attachCover(album *models.Album, privileged bool) error {
+	var cover *models.Image
+	if album.CoverImageID != "" {
+		image, err := s.images.GetByID(album.CoverImageID)
+		if err != nil && !errors.Is(err, ErrImageNotFound) {
+			return err
+		}
+		cover = image
+	} else if album.Images != nil {
+		if len(album.Images) > 0 {
+			cover = album.Images[0]
+		}
+	} else {
+		ids, err := s.repo.ImageIDs(album.ID)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			image, err := s.images.GetByID(ids[0])
+			if err != nil && !errors.Is(err, ErrImageNotFound) {
+				return err
+			}
+			cover = image
+		}
+	}
+
+	if cover == nil || (!privileged && (cover.Visibility == models.VisibilityPrivate || cover.HiddenAt != nil)) {
+		album.Cover = nil
+		return nil
+	}
+	album.Cover = cover
+	return nil
+}
+
+// albumURL — адрес альбома в API; у unlisted альбома с токеном доступа
+func (s *AlbumService) albumURL(album *models.Album) string {
+	u := s.config.BaseURL + "/api/albums/" + album.ID
+	if album.Visibility == models.VisibilityUnlisted {
+		u += "?t=" + url.QueryEscape(album.AccessToken)
+	}
+	return u
+}
+
+func albumName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxAlbumName {
+		return "", fmt.Errorf("%w: name must be between 1 and %d characters", ErrInvalidAlbum, maxAlbumName)
+	}
+	return name, nil
+}
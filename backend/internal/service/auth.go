@@ -2,67 +2,107 @@ package service
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"image-uploader-backend/internal/models"
 	"image-uploader-backend/internal/repository"
 	"strings"
-	"sync"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-type Session struct {
-	UserID    string
-	ExpiresAt time.Time
-}
+const (
+	// SessionTTL — время жизни сессии
+	SessionTTL = 24 * time.Hour
+
+	// lastSeenInterval ограничивает частоту записи last_seen, чтобы
+	// не писать в базу на каждый запрос
+	lastSeenInterval = time.Minute
+)
+
+var (
+	ErrUsernameExists     = errors.New("username already exists")
+	ErrRegistrationClosed = errors.New("registration is closed")
+	ErrInviteRequired     = errors.New("invite code is required")
+	ErrInvalidInvite      = errors.New("invite code is invalid, expired or already used")
+
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrAccountDisabled       = errors.New("account is disabled")
+	ErrPasswordResetRequired = errors.New("password must be changed before logging in")
+)
 
 type AuthService struct {
-	userRepo *repository.UserRepository
-	sessions map[string]*Session
-	mu       sync.RWMutex
+	userRepo         *repository.UserRepository
+	tokenRepo        *repository.TokenRepository
+	inviteRepo       *repository.InviteRepository
+	sessions         repository.SessionStore
+	registrationMode string
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
+func NewAuthService(userRepo *repository.UserRepository, tokenRepo *repository.TokenRepository,
+	inviteRepo *repository.InviteRepository, sessions repository.SessionStore, registrationMode string) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		sessions: make(map[string]*Session),
+		userRepo:         userRepo,
+		tokenRepo:        tokenRepo,
+		inviteRepo:       inviteRepo,
+		sessions:         sessions,
+		registrationMode: registrationMode,
 	}
 }
 
-func (s *AuthService) Register(username, password, role string) (*models.User, error) {
+func (s *AuthService) RegistrationMode() string {
+	return s.registrationMode
+}
+
+// Register регистрирует пользователя с учётом режима регистрации.
+// Публичная регистрация всегда создаёт роль user, другую роль может
+// выдать только инвайт-код.
+func (s *AuthService) Register(username, password, inviteCode string) (*models.User, error) {
+	switch s.registrationMode {
+	case models.RegistrationClosed:
+		return nil, ErrRegistrationClosed
+	case models.RegistrationInvite:
+		if inviteCode == "" {
+			return nil, ErrInviteRequired
+		}
+	}
+
 	// Проверяем, существует ли пользователь
 	_, err := s.userRepo.GetByUsername(username)
 	if err == nil {
 		// Пользователь уже существует
-		return nil, errors.New("username already exists")
+		return nil, ErrUsernameExists
 	}
 	// Если ошибка - это нормально (пользователь не найден), продолжаем
 
-	// Устанавливаем роль по умолчанию
-	if role == "" {
-		role = "user"
-	}
-
 	// Хешируем пароль
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, errors.New("failed to hash password")
 	}
 
-	// Создаем пользователя
+	// Создаем пользователя, роль по инвайту выставит репозиторий
 	user := &models.User{
 		Username:     username,
 		PasswordHash: string(hashedPassword),
-		Role:         role,
+		Role:         models.RoleUser,
 	}
 
-	err = s.userRepo.Create(user)
+	if inviteCode != "" {
+		err = s.inviteRepo.CreateUserWithInvite(inviteCode, user)
+	} else {
+		err = s.userRepo.Create(user)
+	}
 	if err != nil {
+		if errors.Is(err, repository.ErrInviteUnavailable) {
+			return nil, ErrInvalidInvite
+		}
 		// Проверяем, является ли ошибка нарушением UNIQUE constraint
-		if strings.Contains(err.Error(), "UNIQUE constraint") || strings.Contains(err.Error(), "unique constraint") {
-			return nil, errors.New("username already exists")
+		if isUniqueViolation(err) {
+			return nil, ErrUsernameExists
 		}
 		return nil, errors.New("failed to create user")
 	}
@@ -72,32 +112,60 @@ func (s *AuthService) Register(username, password, role string) (*models.User, e
 	return user, nil
 }
 
-func (s *AuthService) Login(username, password string) (string, *models.User, error) {
-	// Получаем пользователя
-	user, err := s.userRepo.GetByUsername(username)
+// EnsureAdmin создаёт администратора при первом запуске, если его ещё нет.
+// Роль admin нельзя получить через публичную регистрацию, поэтому первый
+// администратор задаётся конфигурацией.
+func (s *AuthService) EnsureAdmin(username, password string) (bool, error) {
+	if _, err := s.userRepo.GetByUsername(username); err == nil {
+		return false, nil
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return "", nil, errors.New("invalid credentials")
+		return false, errors.New("failed to hash password")
 	}
 
-	// Проверяем пароль
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	err = s.userRepo.Create(&models.User{
+		Username:     username,
+		PasswordHash: string(hashedPassword),
+		Role:         models.RoleAdmin,
+	})
 	if err != nil {
-		return "", nil, errors.New("invalid credentials")
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *AuthService) Login(username, password, userAgent, ip string) (string, *models.User, error) {
+	user, err := s.checkPassword(username, password)
+	if err != nil {
+		return "", nil, err
+	}
+	// Пароль, сброшенный администратором, сначала меняется через ChangePassword
+	if user.PasswordResetRequired {
+		return "", nil, ErrPasswordResetRequired
 	}
 
 	// Создаем сессию
 	sessionID := generateSessionID()
-	expiresAt := time.Now().Add(24 * time.Hour) // Сессия на 24 часа
+	now := time.Now()
 
-	s.mu.Lock()
-	s.sessions[sessionID] = &Session{
+	err = s.sessions.Create(&models.Session{
+		IDHash:    hashSecret(sessionID),
 		UserID:    user.ID,
-		ExpiresAt: expiresAt,
+		ExpiresAt: now.Add(SessionTTL),
+		CreatedAt: now,
+		LastSeen:  now,
+		UserAgent: userAgent,
+		IP:        ip,
+	})
+	if err != nil {
+		return "", nil, errors.New("failed to create session")
 	}
-	s.mu.Unlock()
 
 	// Очищаем старые сессии периодически (простая очистка при логине)
-	s.cleanExpiredSessions()
+	s.sessions.DeleteExpired(now)
 
 	// Не возвращаем хеш пароля
 	user.PasswordHash = ""
@@ -105,27 +173,39 @@ func (s *AuthService) Login(username, password string) (string, *models.User, er
 }
 
 func (s *AuthService) ValidateSession(sessionID string) (*models.User, error) {
-	s.mu.RLock()
-	session, exists := s.sessions[sessionID]
-	s.mu.RUnlock()
-
-	if !exists {
+	idHash := hashSecret(sessionID)
+	session, err := s.sessions.Get(idHash)
+	if err != nil {
 		return nil, errors.New("invalid session")
 	}
 
 	// Проверяем срок действия
-	if time.Now().After(session.ExpiresAt) {
-		s.mu.Lock()
-		delete(s.sessions, sessionID)
-		s.mu.Unlock()
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
+		s.sessions.Delete(idHash)
 		return nil, errors.New("session expired")
 	}
 
+	if now.Sub(session.LastSeen) > lastSeenInterval {
+		s.sessions.Touch(idHash, now)
+	}
+
 	// Получаем пользователя
 	user, err := s.userRepo.GetByID(session.UserID)
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	// Сессии удаляются при отключении, но сессия могла быть создана
+	// одновременно с ним
+	if user.DisabledAt != nil {
+		s.sessions.Delete(idHash)
+		return nil, ErrAccountDisabled
+	}
+	// То же при сбросе пароля администратором
+	if user.PasswordResetRequired {
+		s.sessions.Delete(idHash)
+		return nil, ErrPasswordResetRequired
+	}
 
 	// Не возвращаем хеш пароля
 	user.PasswordHash = ""
@@ -133,21 +213,51 @@ func (s *AuthService) ValidateSession(sessionID string) (*models.User, error) {
 }
 
 func (s *AuthService) Logout(sessionID string) {
-	s.mu.Lock()
-	delete(s.sessions, sessionID)
-	s.mu.Unlock()
+	s.sessions.Delete(hashSecret(sessionID))
 }
 
-func (s *AuthService) cleanExpiredSessions() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// ChangePassword меняет пароль по текущему. Работает без сессии, чтобы
+// пользователь со сброшенным администратором паролем мог задать свой;
+// все сессии пользователя завершаются.
+func (s *AuthService) ChangePassword(username, currentPassword, newPassword string) error {
+	user, err := s.checkPassword(username, currentPassword)
+	if err != nil {
+		return err
+	}
+	if err := validatePassword(newPassword); err != nil {
+		return err
+	}
+	if newPassword == currentPassword {
+		return fmt.Errorf("%w: new password must differ from the current one", ErrInvalidUser)
+	}
 
-	now := time.Now()
-	for sessionID, session := range s.sessions {
-		if now.After(session.ExpiresAt) {
-			delete(s.sessions, sessionID)
-		}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	if err := s.userRepo.SetPassword(user.ID, string(hashedPassword), false); err != nil {
+		return err
 	}
+
+	return s.sessions.DeleteByUserID(user.ID)
+}
+
+// checkPassword находит пользователя по имени и паролю. Об отключении
+// учетной записи сообщается только после проверки пароля.
+func (s *AuthService) checkPassword(username, password string) (*models.User, error) {
+	user, err := s.userRepo.GetByUsername(username)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if user.DisabledAt != nil {
+		return nil, ErrAccountDisabled
+	}
+	return user, nil
 }
 
 func generateSessionID() string {
@@ -156,3 +266,13 @@ func generateSessionID() string {
 	return hex.EncodeToString(b)
 }
 
+// hashSecret возвращает SHA-256 от секрета (идентификатора сессии или
+// API токена), в хранилище попадает только хеш
+func hashSecret(sessionID string) string {
+	sum := sha256.Sum256([]byte(sessionID))
+	return hex.EncodeToString(sum[:])
+}
+
+func isUniqueViolation(err error) bool {
+	return strings.Contains(err.Error(), "UNIQUE constraint") || strings.Contains(err.Error(), "unique constraint")
+}
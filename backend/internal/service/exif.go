@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	stdimage "image"
+	"image-uploader-backend/internal/metadata"
+	"image-uploader-backend/internal/models"
+	"io"
+)
+
+// rotatedJPEGQuality — качество при перекодировании JPEG после автоповорота.
+// Выше, чем у копий: результат заменяет оригинал.
+const rotatedJPEGQuality = 92
+
+// processMetadata извлекает метаданные и пишет в w содержимое файла для
+// сохранения. Если изображение нужно повернуть и формат можно перекодировать
+// (JPEG, PNG), пиксели поворачиваются, а метаданные при перекодировании
+// пропадают полностью. Иначе метаданные вырезаются согласно EXIF_STRIP
+// без перекодирования. Размеры в info обновляются с учетом поворота.
+func (s *ImageService) processMetadata(w io.Writer, src io.ReaderAt, size int64, info *ImageInfo) (*models.ImageMetadata, error) {
+	meta := metadata.Extract(src, size, info.Format)
+
+	// При EXIF_STRIP=all тег Orientation тоже удаляется, поэтому без поворота
+	// пикселей изображение отображалось бы повернутым
+	rotate := s.config.AutoRotate || s.config.ExifStrip == models.StripAll
+	canEncode := info.MimeType == "image/jpeg" || info.MimeType == "image/png"
+	if rotate && meta.Orientation > 1 && canEncode {
+		img, _, err := stdimage.Decode(io.NewSectionReader(src, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		img = metadata.Orient(img, meta.Orientation)
+
+		if err := encodeImage(w, img, info.MimeType, rotatedJPEGQuality); err != nil {
+			return nil, err
+		}
+		info.Width, info.Height = img.Bounds().Dx(), img.Bounds().Dy()
+		meta.Stripped = models.StripAll
+	} else {
+		if _, err := metadata.Strip(w, src, size, info.Format, s.config.ExifStrip); err != nil {
+			return nil, err
+		}
+		meta.Stripped = s.config.ExifStrip
+	}
+
+	// Координаты сохраняем в базе, только если их разрешено хранить в файле
+	if s.config.ExifStrip != models.StripNone {
+		meta.GPSLatitude = nil
+		meta.GPSLongitude = nil
+	}
+
+	return meta, nil
+}
+
+// attachMetadata заполняет Metadata у изображений, для которых она сохранена
+func (s *ImageService) attachMetadata(images []*models.Image) error {
+	ids := make([]string, len(images))
+	for i, image := range images {
+		ids[i] = image.ID
+	}
+
+	metas, err := s.repo.GetMetadata(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		image.Metadata = metas[image.ID]
+	}
+	return nil
+}
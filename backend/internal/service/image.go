@@ -1,142 +1,617 @@
 package service
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"image-uploader-backend/internal/config"
 	"image-uploader-backend/internal/models"
 	"image-uploader-backend/internal/repository"
+	"image-uploader-backend/internal/storage"
 	"io"
+	"log"
 	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	maxCaptionLength = 1000
+	maxLoggedPaths   = 20 // Сколько непереведенных путей выводить в лог
+)
 
-	"github.com/google/uuid"
+var (
+	ErrImageNotFound  = repository.ErrImageNotFound
+	ErrInvalidCursor  = repository.ErrInvalidCursor
+	ErrInvalidCaption = fmt.Errorf("caption must be at most %d characters", maxCaptionLength)
 )
 
 type ImageService struct {
-	repo   *repository.ImageRepository
-	config *config.Config
-}
+	repo    *repository.ImageRepository
+	albums  *repository.AlbumRepository
+	quotas  *QuotaService
+	storage storage.Storage
+	config  *config.Config
+	signer  *URLSigner
 
-func NewImageService(repo *repository.ImageRepository, cfg *config.Config) *ImageService {
-	// Создаем папку для загрузок если её нет
-	os.MkdirAll(cfg.UploadDir, 0755)
+	// blobMu упорядочивает запись файлов содержимого и их удаление
+	blobMu sync.Mutex
+}
 
+func NewImageService(repo *repository.ImageRepository, albums *repository.AlbumRepository, quotas *QuotaService, store storage.Storage, cfg *config.Config) *ImageService {
 	return &ImageService{
-		repo:   repo,
-		config: cfg,
+		repo:    repo,
+		albums:  albums,
+		quotas:  quotas,
+		storage: store,
+		config:  cfg,
+		signer:  NewURLSigner(cfg.URLSigningKeys),
 	}
 }
 
-// buildImageURL формирует URL для изображения на основе относительного пути
-func (s *ImageService) buildImageURL(relPath string) string {
-	return fmt.Sprintf("%s/images/%s", s.config.BaseURL, relPath)
+// ValidateFile проверяет размер и реальное содержимое файла. Формат определяется
+// по сигнатуре, а не по Content-Type клиента; заявленный тип должен совпадать
+// с обнаруженным.
+func (s *ImageService) ValidateFile(file *multipart.FileHeader) (*ImageInfo, error) {
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	return s.ValidateContent(src, file.Size, file.Header.Get("Content-Type"))
 }
 
-func (s *ImageService) ValidateFile(file *multipart.FileHeader) error {
+// ValidateContent проверяет содержимое файла из произвольного источника
+// (multipart или собранная tus-загрузка) так же, как ValidateFile
+func (s *ImageService) ValidateContent(r io.ReaderAt, size int64, declaredType string) (*ImageInfo, error) {
 	// Проверка размера
-	if file.Size > s.config.MaxFileSize {
-		return fmt.Errorf("file size exceeds maximum allowed size of %d bytes", s.config.MaxFileSize)
+	if size > s.config.MaxFileSize {
+		return nil, fmt.Errorf("file size exceeds maximum allowed size of %d bytes", s.config.MaxFileSize)
+	}
+
+	info, err := InspectImage(r, size, s.imageLimits())
+	if err != nil {
+		return nil, err
 	}
 
 	// Проверка типа файла
-	allowed := false
-	mimeType := file.Header.Get("Content-Type")
+	if !s.isAllowedType(info.MimeType) {
+		return nil, fmt.Errorf("file type %s is not allowed", info.MimeType)
+	}
+
+	declared := NormalizeMimeType(declaredType)
+	if declared != "" && declared != "application/octet-stream" && declared != info.MimeType {
+		return nil, fmt.Errorf("declared content type %s does not match file content %s", declared, info.MimeType)
+	}
+
+	return info, nil
+}
+
+func (s *ImageService) isAllowedType(mimeType string) bool {
 	for _, allowedType := range s.config.AllowedTypes {
-		if mimeType == allowedType {
-			allowed = true
-			break
+		if NormalizeMimeType(allowedType) == mimeType {
+			return true
 		}
 	}
+	return false
+}
 
-	if !allowed {
-		return fmt.Errorf("file type %s is not allowed", mimeType)
+func (s *ImageService) imageLimits() ImageLimits {
+	return ImageLimits{
+		MaxWidth:  s.config.MaxImageWidth,
+		MaxHeight: s.config.MaxImageHeight,
+		MaxPixels: s.config.MaxImagePixels,
 	}
+}
 
-	return nil
+// SaveOptions — необязательные параметры сохранения загрузки
+type SaveOptions struct {
+	// Dedupe — если у пользователя уже есть изображение с тем же содержимым,
+	// вернуть его вместо создания новой записи
+	Dedupe bool
+
+	// Visibility — видимость нового изображения; пусто — DEFAULT_VISIBILITY.
+	// Изображение, возвращенное Dedupe, сохраняет свою видимость.
+	Visibility string
+
+	// AlbumID — альбом пользователя, в конец которого добавляется изображение
+	// (в том числе возвращенное Dedupe); пусто — без альбома
+	AlbumID string
+
+	// Caption — подпись нового изображения
+	Caption string
+
+	// Tags — теги нового изображения; изображению, возвращенному Dedupe,
+	// они добавляются к уже имеющимся, если вместе их не больше maxTagsPerImage
+	Tags []string
+}
+
+// CheckOptions проверяет параметры сохранения так же, как SaveContent,
+// но до чтения файлов: видимость, теги, подпись и альбом пользователя
+func (s *ImageService) CheckOptions(opts SaveOptions, userID string) error {
+	if _, err := s.ResolveVisibility(opts.Visibility); err != nil {
+		return err
+	}
+	if _, err := normalizeTags(opts.Tags); err != nil {
+		return err
+	}
+	if _, err := normalizeCaption(opts.Caption); err != nil {
+		return err
+	}
+	return s.CheckAlbum(opts.AlbumID, userID)
 }
 
-func (s *ImageService) SaveFile(file *multipart.FileHeader, userID string) (*models.Image, error) {
+// SaveFile сохраняет файл, прошедший ValidateFile. Расширение и MIME тип
+// берутся из обнаруженного формата, а не из имени файла клиента.
+// Перед записью на диск из файла извлекаются метаданные, изображение
+// поворачивается по EXIF Orientation и чистится согласно EXIF_STRIP.
+// Файл хранится по SHA-256 сохраняемого содержимого и разделяется между
+// всеми изображениями с тем же содержимым. Второе значение сообщает, что
+// вернулось уже существующее изображение пользователя (SaveOptions.Dedupe).
+// Новое изображение, не помещающееся в квоту пользователя, не сохраняется:
+// возвращается ErrQuotaExceeded.
+func (s *ImageService) SaveFile(file *multipart.FileHeader, info *ImageInfo, userID string, opts SaveOptions) (*models.Image, bool, error) {
 	// Открываем файл
 	src, err := file.Open()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, false, fmt.Errorf("failed to open file: %w", err)
 	}
 	defer src.Close()
 
-	// Генерируем уникальное имя файла
-	ext := filepath.Ext(file.Filename)
-	fileName := uuid.New().String() + ext
-
-	// Создаем структуру папок по дате
-	now := time.Now()
-	datePath := filepath.Join(
-		fmt.Sprintf("%04d", now.Year()),
-		fmt.Sprintf("%02d", now.Month()),
-		fmt.Sprintf("%02d", now.Day()),
-	)
+	return s.SaveContent(src, file.Size, file.Filename, info, userID, opts)
+}
 
-	fullPath := filepath.Join(s.config.UploadDir, datePath)
-	os.MkdirAll(fullPath, 0755)
+// SaveContent сохраняет содержимое размером size, прошедшее ValidateContent,
+// так же, как SaveFile. Обработанный файл пишется во временный файл, а не
+// в память; SHA-256 считается по ходу записи.
+func (s *ImageService) SaveContent(src io.ReaderAt, size int64, originalName string, info *ImageInfo, userID string, opts SaveOptions) (*models.Image, bool, error) {
+	visibility, err := s.ResolveVisibility(opts.Visibility)
+	if err != nil {
+		return nil, false, err
+	}
+	accessToken, err := newAccessToken()
+	if err != nil {
+		return nil, false, err
+	}
+	tags, err := normalizeTags(opts.Tags)
+	if err != nil {
+		return nil, false, err
+	}
+	caption, err := normalizeCaption(opts.Caption)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := s.CheckAlbum(opts.AlbumID, userID); err != nil {
+		return nil, false, err
+	}
 
-	filePath := filepath.Join(fullPath, fileName)
+	staged, err := os.CreateTemp("", "image-upload-*")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(staged.Name())
+	defer staged.Close()
 
-	// Создаем файл на диске
-	dst, err := os.Create(filePath)
+	// Хеш считаем после обработки: одинаковые загрузки дают одинаковый
+	// результат, а адресом файла должно быть то, что реально лежит на диске
+	hasher := sha256.New()
+	meta, err := s.processMetadata(io.MultiWriter(staged, hasher), src, size, info)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create file: %w", err)
+		return nil, false, fmt.Errorf("failed to process image: %w", err)
+	}
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	size, err = staged.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to process image: %w", err)
 	}
-	defer dst.Close()
 
-	// Копируем содержимое
-	if _, err := io.Copy(dst, src); err != nil {
-		return nil, fmt.Errorf("failed to save file: %w", err)
+	if opts.Dedupe {
+		existing, err := s.repo.GetByUserAndHash(userID, hash)
+		if err == nil {
+			if err := s.addTags(existing, tags); err != nil {
+				return nil, false, err
+			}
+			s.addToAlbum(existing, opts.AlbumID)
+			if err := s.populate([]*models.Image{existing}); err != nil {
+				return nil, false, err
+			}
+			return existing, true, nil
+		}
+		if !errors.Is(err, ErrImageNotFound) {
+			return nil, false, err
+		}
 	}
 
-	// Определяем MIME тип более точно
-	mimeType := file.Header.Get("Content-Type")
-	if mimeType == "" {
-		mimeType = "application/octet-stream"
+	// Дубликат выше квоту не расходует; новое изображение должно в нее
+	// поместиться, окончательно это проверяется при записи в базу
+	limits, err := s.quotas.Check(userID, size)
+	if err != nil {
+		return nil, false, err
 	}
 
-	// Формируем относительный путь для URL
-	relPath := strings.ReplaceAll(datePath, string(filepath.Separator), "/") + "/" + fileName
+	// Объект лежит под ключом ab/cd/<sha256>.<ext>, чтобы на диске
+	// не складывать всё в одну папку
+	fileName := hash + info.Extension
+	key := hash[0:2] + "/" + hash[2:4] + "/" + fileName
 
 	// Создаем объект изображения
 	image := &models.Image{
 		UserID:       userID,
-		OriginalName: file.Filename,
+		OriginalName: originalName,
+		Caption:      caption,
 		FileName:     fileName,
-		FilePath:     filePath,
-		MimeType:     mimeType,
-		Size:         file.Size,
-		URL:          s.buildImageURL(relPath),
+		StorageKey:   key,
+		MimeType:     info.MimeType,
+		Size:         size,
+		Width:        info.Width,
+		Height:       info.Height,
+		SHA256:       hash,
+		Visibility:   visibility,
+		AccessToken:  accessToken,
+		Metadata:     meta,
+		Tags:         tags,
+	}
+	if image.Tags == nil {
+		image.Tags = []string{}
 	}
 
-	// Сохраняем в БД
-	if err := s.repo.Create(image); err != nil {
-		// Если не удалось сохранить в БД, удаляем файл
-		os.Remove(filePath)
-		return nil, fmt.Errorf("failed to save to database: %w", err)
+	// Запись файла и счетчик ссылок меняются под одной блокировкой с удалением,
+	// иначе параллельное удаление последней ссылки могло бы стереть только что
+	// переиспользованный файл
+	ctx := context.Background()
+	s.blobMu.Lock()
+	written, err := s.putBlob(ctx, key, staged, size, info.MimeType)
+	if err == nil {
+		err = s.repo.Create(image, limits)
+		if err != nil && written {
+			// Если не удалось сохранить в БД, удаляем файл
+			s.storage.Delete(ctx, key)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to save to database: %w", err)
+		}
+	}
+	s.blobMu.Unlock()
+	if err != nil {
+		return nil, false, err
 	}
 
-	return image, nil
+	image.URL, err = s.fileURL(image, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Уменьшенные копии; ошибка генерации не отменяет загрузку оригинала
+	if err := s.generateVariants(image, staged, size); err != nil {
+		log.Printf("Failed to generate variants for image %s: %v", image.ID, err)
+	}
+	if err := s.attachVariants([]*models.Image{image}); err != nil {
+		log.Printf("Failed to load variants for image %s: %v", image.ID, err)
+	}
+	s.addToAlbum(image, opts.AlbumID)
+
+	return image, false, nil
 }
 
-func (s *ImageService) GetByUserID(userID string) ([]*models.Image, error) {
-	images, err := s.repo.GetByUserID(userID)
+// CheckAlbum проверяет, что albumID (если задан) — альбом пользователя
+func (s *ImageService) CheckAlbum(albumID, userID string) error {
+	if albumID == "" {
+		return nil
+	}
+	album, err := s.albums.GetByID(albumID)
+	if err != nil {
+		return err
+	}
+	if album.UserID != userID {
+		return ErrAlbumNotFound
+	}
+	return nil
+}
+
+// addToAlbum добавляет сохраненное изображение в альбом, проверенный до
+// сохранения. Ошибка не отменяет загрузку: изображение уже сохранено.
+func (s *ImageService) addToAlbum(image *models.Image, albumID string) {
+	if albumID == "" {
+		return
+	}
+	if err := s.albums.AddImages(albumID, []string{image.ID}); err != nil {
+		log.Printf("Failed to add image %s to album %s: %v", image.ID, albumID, err)
+	}
+}
+
+// putBlob записывает содержимое размером size под ключом, если объекта
+// с таким хешем еще нет. Возвращает true, если объект был создан.
+func (s *ImageService) putBlob(ctx context.Context, key string, r io.ReaderAt, size int64, contentType string) (bool, error) {
+	_, err := s.storage.Stat(ctx, key)
+	if err == nil {
+		return false, nil
+	}
+	if !errors.Is(err, storage.ErrNotFound) {
+		return false, fmt.Errorf("failed to check file: %w", err)
+	}
+
+	if err := s.storage.Put(ctx, key, io.NewSectionReader(r, 0, size), size, contentType); err != nil {
+		return false, fmt.Errorf("failed to save file: %w", err)
+	}
+	return true, nil
+}
+
+// List возвращает страницу изображений, отобранных filter. Неверный
+// курсор дает ErrInvalidCursor.
+func (s *ImageService) List(filter models.ImageFilter, page models.PageRequest) (*models.Page[*models.Image], error) {
+	result, err := s.repo.List(filter, page)
 	if err != nil {
 		return nil, err
 	}
 
 	// Формируем URLs для всех изображений
+	if err := s.populate(result.Items); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// populate заполняет URL, варианты, метаданные и теги загруженных из базы изображений
+func (s *ImageService) populate(images []*models.Image) error {
 	for _, image := range images {
-		relPath := strings.TrimPrefix(image.FilePath, s.config.UploadDir+string(filepath.Separator))
-		relPath = strings.ReplaceAll(relPath, string(filepath.Separator), "/")
-		image.URL = s.buildImageURL(relPath)
+		url, err := s.fileURL(image, image.StorageKey)
+		if err != nil {
+			return err
+		}
+		image.URL = url
 	}
+	if err := s.attachVariants(images); err != nil {
+		return err
+	}
+	if err := s.attachMetadata(images); err != nil {
+		return err
+	}
+	return s.attachTags(images)
+}
 
+// ListByAlbum возвращает изображения альбома в порядке альбома
+func (s *ImageService) ListByAlbum(albumID string) ([]*models.Image, error) {
+	images, err := s.repo.GetByAlbumID(albumID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.populate(images); err != nil {
+		return nil, err
+	}
 	return images, nil
 }
+
+func (s *ImageService) GetByID(id string) (*models.Image, error) {
+	image, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.populate([]*models.Image{image}); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// Lookup возвращает изображение без URL, вариантов, метаданных и тегов —
+// для проверок владельца и доступа, когда само изображение не отдается
+func (s *ImageService) Lookup(id string) (*models.Image, error) {
+	return s.repo.GetByID(id)
+}
+
+// IDsByUser возвращает id всех изображений пользователя
+func (s *ImageService) IDsByUser(userID string) ([]string, error) {
+	return s.repo.IDsByUserID(userID)
+}
+
+// Update меняет видимость и (если заданы) подпись и теги изображения. Все
+// значения проверяются до изменений, чтобы ошибка не оставила их
+// примененными частично.
+func (s *ImageService) Update(image *models.Image, req models.UpdateImageRequest) error {
+	var tags []string
+	var caption string
+	var err error
+	if req.Tags != nil {
+		if tags, err = normalizeTags(*req.Tags); err != nil {
+			return err
+		}
+	}
+	if req.Caption != nil {
+		if caption, err = normalizeCaption(*req.Caption); err != nil {
+			return err
+		}
+	}
+	if req.Visibility != "" && !models.ValidVisibility(req.Visibility) {
+		return ErrInvalidVisibility
+	}
+
+	if req.Visibility != "" || (req.Tags == nil && req.Caption == nil) {
+		if err := s.SetVisibility(image, req.Visibility); err != nil {
+			return err
+		}
+	}
+	if req.Caption != nil {
+		if err := s.repo.UpdateCaption(image.ID, caption); err != nil {
+			return err
+		}
+		image.Caption = caption
+	}
+	if req.Tags != nil {
+		return s.SetTags(image, tags)
+	}
+	return nil
+}
+
+// normalizeCaption убирает пробелы по краям подписи и проверяет ее длину
+func normalizeCaption(caption string) (string, error) {
+	caption = strings.TrimSpace(caption)
+	if utf8.RuneCountInString(caption) > maxCaptionLength {
+		return "", ErrInvalidCaption
+	}
+	return caption, nil
+}
+
+// FileAccess — с чем пришел запрос файла
+type FileAccess struct {
+	Viewer *models.User // nil — анонимный запрос
+	Token  string       // Токен unlisted изображения из ссылки
+
+	// Signed — ссылка с проверенной подписью, выданной для варианта SignedVariant
+	Signed        bool
+	SignedVariant string
+}
+
+// FindFile находит в базе файл изображения по ключу хранилища, доступный
+// запросу. Файлы, о которых база не знает, и недоступные файлы считаются
+// несуществующими, чтобы не раскрывать их наличие. Общий файл доступен,
+// если доступно хотя бы одно ссылающееся на него изображение.
+func (s *ImageService) FindFile(key string, access FileAccess) (*models.StoredFile, error) {
+	files, err := s.repo.GetFilesByKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if canView(file, access) {
+			return file, nil
+		}
+	}
+	return nil, ErrImageNotFound
+}
+
+// File возвращает файл изображения: оригинал или копию варианта
+// (если копия не создавалась — оригинал, как в Variants)
+func (s *ImageService) File(image *models.Image, variant string) (*models.StoredFile, error) {
+	key, variant, err := s.variantKey(image, variant)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := s.repo.GetFilesByKey(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.ImageID == image.ID && file.Variant == variant {
+			return file, nil
+		}
+	}
+	return nil, ErrImageNotFound
+}
+
+// OpenFile открывает найденный файл из хранилища. Если запись есть, а объекта
+// нет, возвращается ErrImageNotFound.
+func (s *ImageService) OpenFile(ctx context.Context, file *models.StoredFile) (io.ReadSeekCloser, error) {
+	r, err := s.storage.Get(ctx, file.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Printf("File %s of image %s is missing from storage", file.Key, file.ImageID)
+		return nil, ErrImageNotFound
+	}
+	return r, err
+}
+
+// Delete удаляет изображение из базы, а его файлы из хранилища — если на них
+// больше не ссылается ни одно изображение. Файлы ставятся в очередь удаления
+// в той же транзакции, что и удаление строки, поэтому при сбое на любом шаге
+// они будут дочищены PurgePendingDeletions.
+func (s *ImageService) Delete(image *models.Image) error {
+	keys, err := s.imageKeys(image)
+	if err != nil {
+		return err
+	}
+
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+
+	released, err := s.repo.Delete(image.ID, keys)
+	if err != nil {
+		return err
+	}
+
+	if released {
+		s.purgeObjects(keys)
+	}
+	return nil
+}
+
+// imageKeys возвращает ключи всех файлов изображения: оригинал и уменьшенные копии
+func (s *ImageService) imageKeys(image *models.Image) ([]string, error) {
+	variants, err := s.repo.GetVariants([]string{image.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := []string{image.StorageKey}
+	for _, variant := range variants[image.ID] {
+		keys = append(keys, variant.StorageKey)
+	}
+	return keys, nil
+}
+
+// PurgePendingDeletions удаляет файлы, оставшиеся в очереди после сбоев
+func (s *ImageService) PurgePendingDeletions() error {
+	s.blobMu.Lock()
+	defer s.blobMu.Unlock()
+
+	keys, err := s.repo.GetPendingDeletions()
+	if err != nil {
+		return err
+	}
+
+	s.purgeObjects(keys)
+	return nil
+}
+
+func (s *ImageService) purgeObjects(keys []string) {
+	for _, key := range keys {
+		if err := s.storage.Delete(context.Background(), key); err != nil {
+			// Запись остается в очереди и будет обработана повторно
+			log.Printf("Failed to remove file %s: %v", key, err)
+			continue
+		}
+		if err := s.repo.CompleteDeletion(key); err != nil {
+			log.Printf("Failed to complete deletion of %s: %v", key, err)
+		}
+	}
+}
+
+// BackfillStorageKeys переводит пути на диске, сохраненные до появления
+// ключей хранилища, в ключи относительно UPLOAD_DIR. Выполняется один раз.
+// Если какой-то путь лежит вне UPLOAD_DIR (папку сменили после загрузки),
+// ничего не меняется: такие пути выводятся в лог и возвращается ошибка,
+// чтобы перевод повторили с UPLOAD_DIR, в который загружались файлы.
+func (s *ImageService) BackfillStorageKeys() (int, error) {
+	updated, skipped, err := s.repo.BackfillStorageKeys(func(path string) (string, bool) {
+		// filepath.Rel корректно работает и с UploadDir вида "./uploads",
+		// который filepath.Join при сохранении приводил к "uploads"
+		rel, err := filepath.Rel(s.config.UploadDir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", false
+		}
+		return filepath.ToSlash(rel), true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if len(skipped) > 0 {
+		for i, path := range skipped {
+			if i == maxLoggedPaths {
+				log.Printf("... and %d more", len(skipped)-i)
+				break
+			}
+			log.Printf("File path %s is outside UPLOAD_DIR %s", path, s.config.UploadDir)
+		}
+		return 0, fmt.Errorf("%d file paths are outside UPLOAD_DIR %s; set UPLOAD_DIR to the directory the files were uploaded to and run again",
+			len(skipped), s.config.UploadDir)
+	}
+	return updated, nil
+}
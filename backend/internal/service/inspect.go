@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	// Декодеры поддерживаемых форматов для image.DecodeConfig
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// ImageInfo — формат и размеры изображения, определённые по содержимому файла
+type ImageInfo struct {
+	Format    string // jpeg, png, gif, webp
+	MimeType  string
+	Extension string
+	Width     int
+	Height    int
+}
+
+// ImageLimits ограничивает размеры изображения для защиты от decompression bomb
+type ImageLimits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int64
+}
+
+type imageFormat struct {
+	name      string
+	mimeType  string
+	extension string
+	match     func(header []byte) bool
+	complete  func(r io.ReaderAt, size int64) bool
+}
+
+var imageFormats = []imageFormat{
+	{
+		name:      "jpeg",
+		mimeType:  "image/jpeg",
+		extension: ".jpg",
+		match: func(h []byte) bool {
+			return bytes.HasPrefix(h, []byte{0xFF, 0xD8, 0xFF})
+		},
+		// Файл должен заканчиваться маркером EOI; допускаем мусор после него
+		complete: func(r io.ReaderAt, size int64) bool {
+			return tailContains(r, size, []byte{0xFF, 0xD9})
+		},
+	},
+	{
+		name:      "png",
+		mimeType:  "image/png",
+		extension: ".png",
+		match: func(h []byte) bool {
+			return bytes.HasPrefix(h, []byte("\x89PNG\r\n\x1a\n"))
+		},
+		// Последним должен идти пустой чанк IEND вместе с CRC
+		complete: func(r io.ReaderAt, size int64) bool {
+			return hasSuffixAt(r, size, []byte("\x00\x00\x00\x00IEND\xAE\x42\x60\x82"))
+		},
+	},
+	{
+		name:      "gif",
+		mimeType:  "image/gif",
+		extension: ".gif",
+		match: func(h []byte) bool {
+			return bytes.HasPrefix(h, []byte("GIF87a")) || bytes.HasPrefix(h, []byte("GIF89a"))
+		},
+		// Файл завершается байтом трейлера 0x3B
+		complete: func(r io.ReaderAt, size int64) bool {
+			return hasSuffixAt(r, size, []byte{0x3B})
+		},
+	},
+	{
+		name:      "webp",
+		mimeType:  "image/webp",
+		extension: ".webp",
+		match: func(h []byte) bool {
+			return len(h) >= 12 && bytes.Equal(h[0:4], []byte("RIFF")) && bytes.Equal(h[8:12], []byte("WEBP"))
+		},
+		// Размер RIFF-контейнера из заголовка не должен превышать размер файла
+		complete: func(r io.ReaderAt, size int64) bool {
+			h := make([]byte, 8)
+			if _, err := r.ReadAt(h, 0); err != nil {
+				return false
+			}
+			return int64(binary.LittleEndian.Uint32(h[4:8]))+8 <= size
+		},
+	},
+}
+
+// tailSize — сколько байт с конца файла просматриваем в поисках завершающего маркера
+const tailSize = 4096
+
+// mimeAliases — нестандартные значения Content-Type, которые присылают клиенты
+var mimeAliases = map[string]string{
+	"image/jpg":   "image/jpeg",
+	"image/pjpeg": "image/jpeg",
+	"image/x-png": "image/png",
+}
+
+// NormalizeMimeType приводит синонимы MIME типов к каноническому виду
+func NormalizeMimeType(mimeType string) string {
+	if canonical, ok := mimeAliases[mimeType]; ok {
+		return canonical
+	}
+	return mimeType
+}
+
+// InspectImage определяет формат по сигнатуре файла, разбирает заголовок
+// изображения и проверяет, что файл не обрезан и не превышает лимитов
+func InspectImage(r io.ReaderAt, size int64, limits ImageLimits) (*ImageInfo, error) {
+	header := make([]byte, 16)
+	n, err := r.ReadAt(header, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	header = header[:n]
+
+	var format *imageFormat
+	for i := range imageFormats {
+		if imageFormats[i].match(header) {
+			format = &imageFormats[i]
+			break
+		}
+	}
+	if format == nil {
+		return nil, errors.New("file content is not a supported image (jpeg, png, gif, webp)")
+	}
+
+	cfg, decoded, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil || decoded != format.name {
+		return nil, fmt.Errorf("file is not a well-formed %s image", format.name)
+	}
+
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, errors.New("image has invalid dimensions")
+	}
+	if limits.MaxWidth > 0 && cfg.Width > limits.MaxWidth {
+		return nil, fmt.Errorf("image width %d exceeds maximum of %d pixels", cfg.Width, limits.MaxWidth)
+	}
+	if limits.MaxHeight > 0 && cfg.Height > limits.MaxHeight {
+		return nil, fmt.Errorf("image height %d exceeds maximum of %d pixels", cfg.Height, limits.MaxHeight)
+	}
+	if limits.MaxPixels > 0 && int64(cfg.Width)*int64(cfg.Height) > limits.MaxPixels {
+		return nil, fmt.Errorf("image resolution %dx%d exceeds maximum of %d pixels", cfg.Width, cfg.Height, limits.MaxPixels)
+	}
+
+	if !format.complete(r, size) {
+		return nil, fmt.Errorf("%s image is truncated", format.name)
+	}
+
+	return &ImageInfo{
+		Format:    format.name,
+		MimeType:  format.mimeType,
+		Extension: format.extension,
+		Width:     cfg.Width,
+		Height:    cfg.Height,
+	}, nil
+}
+
+func tailContains(r io.ReaderAt, size int64, marker []byte) bool {
+	offset := size - tailSize
+	if offset < 0 {
+		offset = 0
+	}
+
+	tail := make([]byte, size-offset)
+	if _, err := r.ReadAt(tail, offset); err != nil && !errors.Is(err, io.EOF) {
+		return false
+	}
+	return bytes.Contains(tail, marker)
+}
+
+func hasSuffixAt(r io.ReaderAt, size int64, suffix []byte) bool {
+	if size < int64(len(suffix)) {
+		return false
+	}
+
+	tail := make([]byte, len(suffix))
+	if _, err := r.ReadAt(tail, size-int64(len(suffix))); err != nil && !errors.Is(err, io.EOF) {
+		return false
+	}
+	return bytes.Equal(tail, suffix)
+}
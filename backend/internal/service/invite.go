@@ -0,0 +1,84 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+	"time"
+)
+
+const (
+	maxInviteUses     = 1000
+	maxInviteTTLHours = 24 * 365
+)
+
+var ErrInviteNotFound = errors.New("invite not found")
+
+func (s *AuthService) CreateInvite(createdBy string, req models.CreateInviteRequest) (*models.Invite, error) {
+	role := req.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+
+	maxUses := req.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	if maxUses < 1 || maxUses > maxInviteUses {
+		return nil, fmt.Errorf("max_uses must be between 1 and %d", maxInviteUses)
+	}
+
+	if req.ExpiresInHours < 0 || req.ExpiresInHours > maxInviteTTLHours {
+		return nil, fmt.Errorf("expires_in_hours must be between 0 and %d", maxInviteTTLHours)
+	}
+
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, errors.New("failed to generate invite code")
+	}
+
+	invite := &models.Invite{
+		Code:      code,
+		Role:      role,
+		MaxUses:   maxUses,
+		CreatedBy: createdBy,
+	}
+	if req.ExpiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	if err := s.inviteRepo.Create(invite); err != nil {
+		return nil, errors.New("failed to create invite")
+	}
+
+	return invite, nil
+}
+
+func (s *AuthService) ListInvites() ([]*models.Invite, error) {
+	return s.inviteRepo.GetAllWithRedemptions()
+}
+
+func (s *AuthService) RevokeInvite(id string) error {
+	err := s.inviteRepo.Revoke(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrInviteNotFound
+	}
+	return err
+}
+
+// generateInviteCode возвращает код без неоднозначных символов,
+// который удобно передать вручную
+func generateInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
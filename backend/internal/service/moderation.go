@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"time"
+)
+
+const maxModerationBatch = 500
+
+var ErrInvalidModeration = errors.New("invalid moderation request")
+
+// ModerationService — просмотр изображений всех пользователей и действия
+// администратора над ними
+type ModerationService struct {
+	images *ImageService
+	users  *repository.UserRepository
+}
+
+func NewModerationService(images *ImageService, users *repository.UserRepository) *ModerationService {
+	return &ModerationService{
+		images: images,
+		users:  users,
+	}
+}
+
+// List возвращает страницу изображений всех пользователей (или filter.UserID)
+// с именами владельцев
+func (s *ModerationService) List(filter models.ImageFilter, page models.PageRequest) (*models.Page[*models.AdminImage], error) {
+	return s.images.ListWithOwner(filter, page)
+}
+
+// Check проверяет действие и его параметры до обработки изображений,
+// чтобы неверный запрос не был применен к части из них
+func (s *ModerationService) Check(req models.BulkImageRequest) error {
+	if len(req.ImageIDs) == 0 || len(req.ImageIDs) > maxModerationBatch {
+		return fmt.Errorf("%w: image_ids must contain between 1 and %d images", ErrInvalidModeration, maxModerationBatch)
+	}
+
+	switch req.Action {
+	case models.ModerationDelete, models.ModerationHide, models.ModerationUnhide,
+		models.ModerationFlag, models.ModerationUnflag:
+	case models.ModerationVisibility:
+		if !models.ValidVisibility(req.Visibility) {
+			return ErrInvalidVisibility
+		}
+	case models.ModerationMove:
+		if req.UserID == "" {
+			return fmt.Errorf("%w: user_id is required to move images", ErrInvalidModeration)
+		}
+		if _, err := s.users.GetByID(req.UserID); err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				return fmt.Errorf("%w: user_id user not found", ErrInvalidModeration)
+			}
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidModeration, req.Action)
+	}
+	return nil
+}
+
+// Apply применяет проверенное Check действие к одному изображению.
+// Переданное другому пользователю изображение учитывается в его квоте,
+// даже если она превышена.
+func (s *ModerationService) Apply(req models.BulkImageRequest, imageID string) error {
+	image, err := s.images.Lookup(imageID)
+	if err != nil {
+		return err
+	}
+
+	switch req.Action {
+	case models.ModerationDelete:
+		return s.images.Delete(image)
+	case models.ModerationHide:
+		return s.images.SetHidden(image, true)
+	case models.ModerationUnhide:
+		return s.images.SetHidden(image, false)
+	case models.ModerationFlag:
+		return s.images.SetFlagged(image, true)
+	case models.ModerationUnflag:
+		return s.images.SetFlagged(image, false)
+	case models.ModerationVisibility:
+		return s.images.SetVisibility(image, req.Visibility)
+	case models.ModerationMove:
+		return s.images.Move(image, req.UserID)
+	}
+	return fmt.Errorf("%w: unknown action %q", ErrInvalidModeration, req.Action)
+}
+
+// ListWithOwner возвращает страницу изображений, отобранных filter,
+// с именами владельцев
+func (s *ImageService) ListWithOwner(filter models.ImageFilter, page models.PageRequest) (*models.Page[*models.AdminImage], error) {
+	result, err := s.repo.ListWithOwner(filter, page)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*models.Image, len(result.Items))
+	for i, item := range result.Items {
+		images[i] = item.Image
+	}
+	if err := s.populate(images); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetHidden скрывает изображение от всех, кроме владельца и администраторов,
+// или снимает скрытие. Повторное скрытие не меняет время скрытия.
+func (s *ImageService) SetHidden(image *models.Image, hidden bool) error {
+	if hidden == (image.HiddenAt != nil) {
+		return nil
+	}
+
+	var hiddenAt *time.Time
+	if hidden {
+		now := time.Now().UTC()
+		hiddenAt = &now
+	}
+	if err := s.repo.SetHidden(image.ID, hiddenAt); err != nil {
+		return err
+	}
+	image.HiddenAt = hiddenAt
+	return nil
+}
+
+// SetFlagged отмечает изображение для проверки или снимает отметку
+func (s *ImageService) SetFlagged(image *models.Image, flagged bool) error {
+	if flagged == (image.FlaggedAt != nil) {
+		return nil
+	}
+
+	var flaggedAt *time.Time
+	if flagged {
+		now := time.Now().UTC()
+		flaggedAt = &now
+	}
+	if err := s.repo.SetFlagged(image.ID, flaggedAt); err != nil {
+		return err
+	}
+	image.FlaggedAt = flaggedAt
+	return nil
+}
+
+// Move передает изображение пользователю userID вместе с местом в его квоте;
+// из альбомов и ссылок прежнего владельца изображение убирается
+func (s *ImageService) Move(image *models.Image, userID string) error {
+	if image.UserID == userID {
+		return nil
+	}
+
+	if err := s.repo.Move(image.ID, userID); err != nil {
+		return err
+	}
+	image.UserID = userID
+	return nil
+}
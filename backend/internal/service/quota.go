@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+)
+
+var (
+	ErrQuotaExceeded = repository.ErrQuotaExceeded
+	ErrInvalidQuota  = errors.New("quota limits must not be negative")
+)
+
+// QuotaService определяет действующие лимиты пользователя: заданные
+// администратором, иначе лимиты роли из QUOTA_ROLES, иначе общие
+// QUOTA_MAX_MB и QUOTA_MAX_IMAGES
+type QuotaService struct {
+	repo   *repository.QuotaRepository
+	config *config.Config
+}
+
+func NewQuotaService(repo *repository.QuotaRepository, cfg *config.Config) *QuotaService {
+	return &QuotaService{
+		repo:   repo,
+		config: cfg,
+	}
+}
+
+// Get возвращает использование, действующие лимиты и лимиты, заданные
+// администратором
+func (s *QuotaService) Get(userID string) (*models.UserQuota, error) {
+	quota, err := s.repo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, ok := s.config.RoleQuotas[quota.Role]
+	if !ok {
+		limits = s.config.DefaultQuota
+	}
+	quota.MaxBytes = limits.MaxBytes
+	quota.MaxImages = limits.MaxImages
+	if quota.Override.MaxBytes != nil {
+		quota.MaxBytes = *quota.Override.MaxBytes
+	}
+	if quota.Override.MaxImages != nil {
+		quota.MaxImages = *quota.Override.MaxImages
+	}
+	return quota, nil
+}
+
+// Usage возвращает использование хранилища и действующие лимиты
+func (s *QuotaService) Usage(userID string) (*models.QuotaUsage, error) {
+	quota, err := s.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &quota.QuotaUsage, nil
+}
+
+// SetOverride задает пользователю свои лимиты; nil в поле возвращает
+// лимит по умолчанию
+func (s *QuotaService) SetOverride(userID string, override models.QuotaOverride) (*models.UserQuota, error) {
+	if (override.MaxBytes != nil && *override.MaxBytes < 0) || (override.MaxImages != nil && *override.MaxImages < 0) {
+		return nil, ErrInvalidQuota
+	}
+	// Проверяем пользователя, чтобы не создавать запись для несуществующего
+	if _, err := s.repo.Get(userID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetOverride(userID, override); err != nil {
+		return nil, err
+	}
+	return s.Get(userID)
+}
+
+// Check проверяет, что изображение размером size помещается в квоту,
+// и возвращает лимиты для окончательной проверки при сохранении. Проверка
+// заранее позволяет не записывать файл, который все равно будет отклонен.
+func (s *QuotaService) Check(userID string, size int64) (models.QuotaLimits, error) {
+	usage, err := s.Usage(userID)
+	if err != nil {
+		return models.QuotaLimits{}, err
+	}
+	if (usage.MaxBytes > 0 && usage.BytesUsed+size > usage.MaxBytes) ||
+		(usage.MaxImages > 0 && usage.ImagesUsed+1 > usage.MaxImages) {
+		return usage.QuotaLimits, ErrQuotaExceeded
+	}
+	return usage.QuotaLimits, nil
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"html"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	maxSearchTerms = 10
+	maxSearchLimit = 100
+)
+
+var ErrInvalidSearch = errors.New("q must contain at least one word")
+
+// Search ищет изображения по словам запроса q в имени файла, подписи и тегах.
+// Каждое слово ищется как префикс, изображение должно содержать все слова.
+// Пользователь ищет среди своих изображений, администратор — среди всех
+// (или изображений userID, если он задан). Интервал дат [from, to) относится
+// ко времени загрузки; нулевые границы не ограничивают.
+func (s *ImageService) Search(viewer *models.User, q, userID string, from, to time.Time, limit int) ([]*models.ImageSearchResult, error) {
+	query := ftsQuery(q)
+	if query == "" {
+		return nil, ErrInvalidSearch
+	}
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	if viewer.Role != models.RoleAdmin {
+		userID = viewer.ID
+	}
+
+	results, err := s.repo.Search(models.ImageSearch{
+		Query:  query,
+		UserID: userID,
+		From:   from,
+		To:     to,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]*models.Image, len(results))
+	for i, result := range results {
+		images[i] = result.Image
+		result.Highlights.OriginalName = markHighlights(result.Highlights.OriginalName)
+		result.Highlights.Caption = markHighlights(result.Highlights.Caption)
+		result.Highlights.Tags = markHighlights(result.Highlights.Tags)
+	}
+	if err := s.populate(images); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ftsQuery превращает пользовательский запрос в выражение FTS5: слова
+// разбиваются так же, как токенизатор unicode61, и ищутся как префиксы.
+// Синтаксис FTS5 из запроса не используется, поэтому запрос не может
+// оказаться некорректным.
+func ftsQuery(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) > maxSearchTerms {
+		words = words[:maxSearchTerms]
+	}
+
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + word + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// markHighlights экранирует текст подсветки для HTML и заменяет маркеры
+// совпадений на <mark>
+func markHighlights(s string) string {
+	s = html.EscapeString(s)
+	s = strings.ReplaceAll(s, repository.HighlightStart, "<mark>")
+	return strings.ReplaceAll(s, repository.HighlightEnd, "</mark>")
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"net/url"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	maxShareImages      = 100
+	maxShareViews       = 1_000_000
+	maxShareTTLHours    = 24 * 365
+	minSharePassword    = 4
+	maxSharePassword    = 72 // Ограничение bcrypt
+	shareViewFilesTTL   = time.Hour
+	shareSlugRandomSize = 12
+)
+
+var (
+	ErrShareNotFound         = errors.New("share link not found")
+	ErrSharePasswordRequired = errors.New("share link is protected by a password")
+	ErrShareInvalidPassword  = errors.New("invalid password")
+)
+
+// ShareService управляет ссылками /s/<slug> на изображения. Каждое открытие
+// ссылки засчитывается как просмотр и выдает токен, по которому файлы
+// изображений доступны shareViewFilesTTL, — так ограничение числа
+// просмотров не обходится повторной загрузкой файлов.
+type ShareService struct {
+	repo   *repository.ShareRepository
+	images *ImageService
+	config *config.Config
+}
+
+func NewShareService(repo *repository.ShareRepository, images *ImageService, cfg *config.Config) *ShareService {
+	return &ShareService{
+		repo:   repo,
+		images: images,
+		config: cfg,
+	}
+}
+
+// Create создает ссылку на изображения пользователя. Чужие и несуществующие
+// изображения дают ErrImageNotFound, прочие ошибки проверки — описание для клиента.
+func (s *ShareService) Create(userID string, req models.CreateShareLinkRequest) (*models.ShareLink, error) {
+	if len(req.ImageIDs) == 0 || len(req.ImageIDs) > maxShareImages {
+		return nil, fmt.Errorf("image_ids must contain between 1 and %d images", maxShareImages)
+	}
+	if req.MaxViews < 0 || req.MaxViews > maxShareViews {
+		return nil, fmt.Errorf("max_views must be between 0 and %d", maxShareViews)
+	}
+	if req.ExpiresInHours < 0 || req.ExpiresInHours > maxShareTTLHours {
+		return nil, fmt.Errorf("expires_in_hours must be between 0 and %d", maxShareTTLHours)
+	}
+	if req.Password != "" && (len(req.Password) < minSharePassword || len(req.Password) > maxSharePassword) {
+		return nil, fmt.Errorf("password must be between %d and %d characters", minSharePassword, maxSharePassword)
+	}
+
+	var imageIDs []string
+	for _, id := range req.ImageIDs {
+		if slices.Contains(imageIDs, id) {
+			continue
+		}
+		image, err := s.images.Lookup(id)
+		if err != nil {
+			return nil, err
+		}
+		if image.UserID != userID {
+			return nil, ErrImageNotFound
+		}
+		imageIDs = append(imageIDs, id)
+	}
+
+	slug, err := randomToken(shareSlugRandomSize)
+	if err != nil {
+		return nil, errors.New("failed to generate share link")
+	}
+
+	link := &models.ShareLink{
+		Slug:     slug,
+		UserID:   userID,
+		ImageIDs: imageIDs,
+		MaxViews: req.MaxViews,
+	}
+	if req.ExpiresInHours > 0 {
+		expiresAt := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		link.ExpiresAt = &expiresAt
+	}
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, errors.New("failed to hash password")
+		}
+		link.PasswordHash = string(hash)
+		link.HasPassword = true
+	}
+
+	if err := s.repo.Create(link); err != nil {
+		return nil, errors.New("failed to create share link")
+	}
+
+	s.decorate(link, time.Now())
+	return link, nil
+}
+
+// ListByUser возвращает ссылки пользователя со статистикой просмотров
+func (s *ShareService) ListByUser(userID string) ([]*models.ShareLink, error) {
+	links, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	s.decorateAll(links)
+	return links, nil
+}
+
+// ListAll возвращает ссылки всех пользователей
+func (s *ShareService) ListAll() ([]*models.ShareLink, error) {
+	links, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	s.decorateAll(links)
+	return links, nil
+}
+
+// Revoke отзывает ссылку пользователя; пустой userID — любую ссылку.
+// Уже выданные токены просмотра перестают открывать файлы.
+func (s *ShareService) Revoke(id, userID string) error {
+	err := s.repo.Revoke(id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrShareNotFound
+	}
+	return err
+}
+
+// Open засчитывает просмотр ссылки и возвращает ее изображения с URL файлов.
+// Недоступная ссылка выглядит так же, как несуществующая. Неверный пароль
+// просмотр не засчитывает.
+func (s *ShareService) Open(slug, password string) (*models.SharedView, error) {
+	link, err := s.repo.GetBySlug(slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !shareActive(link, now) {
+		return nil, ErrShareNotFound
+	}
+
+	if link.HasPassword {
+		if password == "" {
+			return nil, ErrSharePasswordRequired
+		}
+		if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+			return nil, ErrShareInvalidPassword
+		}
+	}
+
+	token, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	filesExpireAt := now.Add(shareViewFilesTTL)
+	if link.ExpiresAt != nil && link.ExpiresAt.Before(filesExpireAt) {
+		filesExpireAt = *link.ExpiresAt
+	}
+
+	viewCount, err := s.repo.RecordView(link.ID, token, now, filesExpireAt)
+	if errors.Is(err, repository.ErrShareUnavailable) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	view := &models.SharedView{
+		Slug:           link.Slug,
+		Images:         []*models.SharedImage{},
+		ExpiresAt:      link.ExpiresAt,
+		FilesExpiresAt: filesExpireAt,
+	}
+	if link.MaxViews > 0 {
+		remaining := link.MaxViews - viewCount
+		view.RemainingViews = &remaining
+	}
+
+	for _, id := range link.ImageIDs {
+		image, err := s.images.GetByID(id)
+		if errors.Is(err, ErrImageNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Скрытое администратором изображение ссылка не показывает
+		if image.HiddenAt != nil {
+			continue
+		}
+		view.Images = append(view.Images, s.sharedImage(link, image, token))
+	}
+
+	return view, nil
+}
+
+// sharedImage описывает изображение ссылки с URL файлов через нее
+func (s *ShareService) sharedImage(link *models.ShareLink, image *models.Image, token string) *models.SharedImage {
+	base := s.config.BaseURL + "/s/" + link.Slug + "/images/" + image.ID
+	query := "?vt=" + url.QueryEscape(token)
+
+	shared := &models.SharedImage{
+		ID:           image.ID,
+		OriginalName: image.OriginalName,
+		MimeType:     image.MimeType,
+		Width:        image.Width,
+		Height:       image.Height,
+		URL:          base + query,
+		Variants:     make(map[string]string),
+	}
+	for name := range image.Variants {
+		shared.Variants[name] = base + "/" + url.PathEscape(name) + query
+	}
+	return shared
+}
+
+// FindFile находит файл изображения ссылки (variant пуст — оригинал) по
+// токену просмотра. Файлы доступны, пока действует токен и ссылка
+// не отозвана и не истекла; исчерпание просмотров на них не влияет.
+func (s *ShareService) FindFile(slug, imageID, variant, token string) (*models.StoredFile, error) {
+	link, err := s.repo.GetBySlug(slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if link.RevokedAt != nil || (link.ExpiresAt != nil && !now.Before(*link.ExpiresAt)) {
+		return nil, ErrShareNotFound
+	}
+	if !slices.Contains(link.ImageIDs, imageID) || token == "" {
+		return nil, ErrShareNotFound
+	}
+
+	valid, err := s.repo.ValidView(link.ID, token, now)
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrShareNotFound
+	}
+
+	image, err := s.images.Lookup(imageID)
+	if errors.Is(err, ErrImageNotFound) || (err == nil && image.HiddenAt != nil) {
+		return nil, ErrShareNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := s.images.File(image, variant)
+	if errors.Is(err, ErrImageNotFound) || errors.Is(err, ErrUnknownVariant) {
+		return nil, ErrShareNotFound
+	}
+	return file, err
+}
+
+func (s *ShareService) decorateAll(links []*models.ShareLink) {
+	now := time.Now()
+	for _, link := range links {
+		s.decorate(link, now)
+	}
+}
+
+// decorate заполняет вычисляемые поля ссылки
+func (s *ShareService) decorate(link *models.ShareLink, now time.Time) {
+	link.URL = s.config.BaseURL + "/s/" + link.Slug
+	link.Active = shareActive(link, now)
+}
+
+// shareActive сообщает, можно ли открыть ссылку в момент now
+func shareActive(link *models.ShareLink, now time.Time) bool {
+	if link.RevokedAt != nil {
+		return false
+	}
+	if link.ExpiresAt != nil && !now.Before(*link.ExpiresAt) {
+		return false
+	}
+	return link.MaxViews == 0 || link.ViewCount < link.MaxViews
+}
+
+// randomToken возвращает n случайных байт в base64url без дополнения
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/models"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrSigningDisabled  = errors.New("signed URLs are not configured")
+	ErrInvalidSignature = errors.New("invalid URL signature")
+	ErrSignatureExpired = errors.New("signed URL has expired")
+	ErrInvalidTTL       = errors.New("ttl exceeds maximum allowed lifetime")
+	ErrUnknownVariant   = errors.New("unknown image variant")
+)
+
+// URLSigner подписывает ссылки на файлы HMAC-SHA256. Подпись покрывает путь,
+// срок действия и имя варианта, поэтому ссылку нельзя продлить или перенести
+// на другой файл. Новые ссылки подписываются первым ключом, проверяются
+// ключом из параметра kid — так старые ссылки работают после ротации,
+// пока их ключ остается в списке.
+type URLSigner struct {
+	keys   map[string][]byte
+	active string
+}
+
+func NewURLSigner(keys []config.SigningKey) *URLSigner {
+	signer := &URLSigner{keys: make(map[string][]byte)}
+	for i, key := range keys {
+		if i == 0 {
+			signer.active = key.ID
+		}
+		signer.keys[key.ID] = []byte(key.Secret)
+	}
+	return signer
+}
+
+// Enabled сообщает, настроен ли хотя бы один ключ
+func (s *URLSigner) Enabled() bool {
+	return s.active != ""
+}
+
+// Sign возвращает параметры подписанной ссылки: exp, kid, v (если задан
+// вариант) и sig
+func (s *URLSigner) Sign(path, variant string, expires time.Time) (url.Values, error) {
+	if !s.Enabled() {
+		return nil, ErrSigningDisabled
+	}
+
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{}
+	query.Set("exp", exp)
+	query.Set("kid", s.active)
+	if variant != "" {
+		query.Set("v", variant)
+	}
+	query.Set("sig", s.signature(s.keys[s.active], path, exp, variant))
+	return query, nil
+}
+
+// Verify проверяет параметры подписанной ссылки на path и возвращает
+// вариант, для которого она выдана
+func (s *URLSigner) Verify(path string, query url.Values, now time.Time) (string, error) {
+	secret, ok := s.keys[query.Get("kid")]
+	if !ok {
+		return "", ErrInvalidSignature
+	}
+
+	exp, variant := query.Get("exp"), query.Get("v")
+	expected := s.signature(secret, path, exp, variant)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("sig"))) {
+		return "", ErrInvalidSignature
+	}
+
+	// Срок проверяется после подписи: exp подобрать нельзя
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	if now.Unix() > expires {
+		return "", ErrSignatureExpired
+	}
+	return variant, nil
+}
+
+func (s *URLSigner) signature(secret []byte, path, exp, variant string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(path + "\n" + exp + "\n" + variant))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// SignURL выдает ссылку на файл изображения (variant пуст — оригинал),
+// действующую ttl; ttl <= 0 — срок по умолчанию. Если копия варианта
+// не создавалась, ссылка ведет на оригинал, как и в Variants.
+func (s *ImageService) SignURL(image *models.Image, variant string, ttl time.Duration) (string, time.Time, error) {
+	if !s.signer.Enabled() {
+		return "", time.Time{}, ErrSigningDisabled
+	}
+	if ttl <= 0 {
+		ttl = s.config.SignedURLDefaultTTL
+	}
+	if ttl > s.config.SignedURLMaxTTL {
+		return "", time.Time{}, ErrInvalidTTL
+	}
+
+	key, variant, err := s.variantKey(image, variant)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	path := "/images/" + key
+	expires := time.Now().Add(ttl).Truncate(time.Second)
+	query, err := s.signer.Sign(path, variant, expires)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return s.config.BaseURL + path + "?" + query.Encode(), expires, nil
+}
+
+// variantKey возвращает ключ файла варианта и имя варианта, которым он
+// хранится (пустое для оригинала)
+func (s *ImageService) variantKey(image *models.Image, variant string) (string, string, error) {
+	if variant == "" {
+		return image.StorageKey, "", nil
+	}
+
+	configured := false
+	for _, spec := range s.config.Variants {
+		if spec.Name == variant {
+			configured = true
+		}
+	}
+	if !configured {
+		return "", "", ErrUnknownVariant
+	}
+
+	variants, err := s.repo.GetVariants([]string{image.ID})
+	if err != nil {
+		return "", "", err
+	}
+	for _, v := range variants[image.ID] {
+		if v.Name == variant {
+			return v.StorageKey, variant, nil
+		}
+	}
+	return image.StorageKey, "", nil
+}
+
+// VerifySignature проверяет подписанную ссылку на файл по пути path
+// и возвращает вариант, для которого она выдана
+func (s *ImageService) VerifySignature(path string, query url.Values) (string, error) {
+	return s.signer.Verify(path, query, time.Now())
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"image-uploader-backend/internal/config"
+)
+
+func TestURLSignerVerify(t *testing.T) {
+	oldKey := config.SigningKey{ID: "2024a", Secret: "old-secret"}
+	newKey := config.SigningKey{ID: "2024b", Secret: "new-secret"}
+
+	// До ротации ссылки подписывались старым ключом, после — новым,
+	// старый остается в списке для проверки
+	before := NewURLSigner([]config.SigningKey{oldKey})
+	after := NewURLSigner([]config.SigningKey{newKey, oldKey})
+	retired := NewURLSigner([]config.SigningKey{newKey})
+
+	const path = "/images/ab/abcdef.jpg"
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	expires := now.Add(time.Hour)
+
+	sign := func(signer *URLSigner, variant string) url.Values {
+		t.Helper()
+		query, err := signer.Sign(path, variant, expires)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return query
+	}
+	with := func(query url.Values, key, value string) url.Values {
+		changed := url.Values{}
+		for k, v := range query {
+			changed[k] = append([]string(nil), v...)
+		}
+		if value == "" {
+			changed.Del(key)
+		} else {
+			changed.Set(key, value)
+		}
+		return changed
+	}
+
+	tests := []struct {
+		name        string
+		signer      *URLSigner
+		path        string
+		query       url.Values
+		now         time.Time
+		wantVariant string
+		wantErr     error
+	}{
+		{"original", after, path, sign(after, ""), now, "", nil},
+		{"variant", after, path, sign(after, "thumb"), now, "thumb", nil},
+		{"last second", after, path, sign(after, ""), expires, "", nil},
+		{"expired", after, path, sign(after, ""), expires.Add(time.Second), "", ErrSignatureExpired},
+		{"old key after rotation", after, path, sign(before, "thumb"), now, "thumb", nil},
+		{"old key removed", retired, path, sign(before, ""), now, "", ErrInvalidSignature},
+		{"unknown kid", after, path, with(sign(after, ""), "kid", "2023z"), now, "", ErrInvalidSignature},
+		{"missing kid", after, path, with(sign(after, ""), "kid", ""), now, "", ErrInvalidSignature},
+		{"kid of other key", after, path, with(sign(after, ""), "kid", oldKey.ID), now, "", ErrInvalidSignature},
+		{"other path", after, "/images/ab/other.jpg", sign(after, ""), now, "", ErrInvalidSignature},
+		{"extended exp", after, path, with(sign(after, ""), "exp", "9999999999"), now, "", ErrInvalidSignature},
+		{"extended expired exp", after, path, with(sign(after, ""), "exp", "9999999999"), expires.Add(time.Hour), "", ErrInvalidSignature},
+		{"other variant", after, path, with(sign(after, "thumb"), "v", "large"), now, "", ErrInvalidSignature},
+		{"variant dropped", after, path, with(sign(after, "thumb"), "v", ""), now, "", ErrInvalidSignature},
+		{"tampered sig", after, path, with(sign(after, ""), "sig", "AAAA"), now, "", ErrInvalidSignature},
+		{"missing sig", after, path, with(sign(after, ""), "sig", ""), now, "", ErrInvalidSignature},
+		{"signing disabled", NewURLSigner(nil), path, sign(after, ""), now, "", ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, err := tt.signer.Verify(tt.path, tt.query, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if variant != tt.wantVariant {
+				t.Errorf("variant = %q, want %q", variant, tt.wantVariant)
+			}
+		})
+	}
+}
+
+func TestURLSignerSignUsesActiveKey(t *testing.T) {
+	signer := NewURLSigner([]config.SigningKey{{ID: "2024b", Secret: "new"}, {ID: "2024a", Secret: "old"}})
+	query, err := signer.Sign("/images/a.jpg", "", time.Now())
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	if kid := query.Get("kid"); kid != "2024b" {
+		t.Errorf("kid = %q, want 2024b", kid)
+	}
+
+	if _, err := NewURLSigner(nil).Sign("/images/a.jpg", "", time.Now()); !errors.Is(err, ErrSigningDisabled) {
+		t.Errorf("err = %v, want ErrSigningDisabled", err)
+	}
+}
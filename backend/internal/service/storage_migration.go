@@ -0,0 +1,193 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"image-uploader-backend/internal/storage"
+	"io"
+	"log"
+	"mime"
+	"path"
+)
+
+// StorageMigrationOptions — параметры переноса файлов
+type StorageMigrationOptions struct {
+	DryRun    bool // Только проверить источник и посчитать, что будет перенесено
+	BatchSize int  // Сколько объектов обрабатывать и записывать в базу за раз
+}
+
+// StorageMigrationReport — итог переноса
+type StorageMigrationReport struct {
+	Total   int   // Объектов, на которые ссылается база
+	Copied  int   // Скопировано (при DryRun — будет скопировано)
+	Skipped int   // Уже перенесены ранее
+	Failed  int   // Не удалось скопировать или проверить
+	Bytes   int64 // Объем скопированного
+}
+
+// StorageMigrator переносит все файлы, на которые ссылается база, из одного
+// хранилища в другое. Ключи объектов от хранилища не зависят, поэтому записи
+// изображений не меняются: после переноса достаточно переключить настройки
+// хранилища. Файлы, на которые база не ссылается, не переносятся.
+type StorageMigrator struct {
+	repo   *repository.StorageMigrationRepository
+	source storage.Storage
+	target storage.Storage
+
+	// targetLocation отличает прогресс переноса в разные хранилища
+	targetLocation string
+}
+
+func NewStorageMigrator(repo *repository.StorageMigrationRepository, source, target storage.Storage, targetLocation string) *StorageMigrator {
+	return &StorageMigrator{
+		repo:           repo,
+		source:         source,
+		target:         target,
+		targetLocation: targetLocation,
+	}
+}
+
+// Run копирует объекты пачками. Каждый объект после записи перечитывается
+// из цели и сверяется по размеру и SHA-256; проверенные объекты пачки
+// отмечаются в базе одной транзакцией. Повторный запуск пропускает
+// отмеченные объекты, поэтому прерванный перенос продолжается с места
+// остановки, а запуск после новых загрузок докопирует только их.
+func (m *StorageMigrator) Run(ctx context.Context, opts StorageMigrationOptions) (*StorageMigrationReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	report := &StorageMigrationReport{}
+	after := ""
+	for batch := 1; ; batch++ {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		objects, err := m.repo.ListObjects(after, opts.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(objects) == 0 {
+			return report, nil
+		}
+		after = objects[len(objects)-1].Key
+
+		keys := make([]string, len(objects))
+		for i, obj := range objects {
+			keys[i] = obj.Key
+		}
+		copied, err := m.repo.CopiedKeys(m.targetLocation, keys)
+		if err != nil {
+			return report, err
+		}
+
+		var done []models.StoredObject
+		for _, obj := range objects {
+			report.Total++
+			if copied[obj.Key] {
+				report.Skipped++
+				continue
+			}
+
+			if opts.DryRun {
+				err = m.checkSource(ctx, obj)
+			} else {
+				err = m.copyObject(ctx, obj)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					// Уже проверенные объекты пачки сохраняем, чтобы не копировать их снова
+					break
+				}
+				log.Printf("Failed to migrate %s: %v", obj.Key, err)
+				report.Failed++
+				continue
+			}
+
+			done = append(done, obj)
+			report.Copied++
+			report.Bytes += obj.Size
+		}
+
+		if !opts.DryRun && len(done) > 0 {
+			if err := m.repo.MarkCopied(m.targetLocation, done); err != nil {
+				return report, fmt.Errorf("failed to record progress: %w", err)
+			}
+		}
+		log.Printf("Batch %d: %d objects, %d copied, %d skipped, %d failed so far",
+			batch, len(objects), report.Copied, report.Skipped, report.Failed)
+	}
+}
+
+// checkSource проверяет, что объект есть в источнике и его размер совпадает с базой
+func (m *StorageMigrator) checkSource(ctx context.Context, obj models.StoredObject) error {
+	info, err := m.source.Stat(ctx, obj.Key)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	if info.Size != obj.Size {
+		return fmt.Errorf("source size %d does not match database size %d", info.Size, obj.Size)
+	}
+	return nil
+}
+
+// copyObject копирует объект и проверяет копию. Если в цели уже лежит
+// такой же объект (например, перенос прервался до записи прогресса),
+// он не перезаписывается.
+func (m *StorageMigrator) copyObject(ctx context.Context, obj models.StoredObject) error {
+	data, err := readObject(ctx, m.source, obj.Key)
+	if err != nil {
+		return fmt.Errorf("source: %w", err)
+	}
+	if int64(len(data)) != obj.Size {
+		return fmt.Errorf("source size %d does not match database size %d", len(data), obj.Size)
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	if obj.SHA256 != "" && hash != obj.SHA256 {
+		return fmt.Errorf("source checksum %s does not match database checksum %s", hash, obj.SHA256)
+	}
+
+	if err := m.verifyTarget(ctx, obj.Key, int64(len(data)), hash); err == nil {
+		return nil
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(obj.Key))
+	if err := m.target.Put(ctx, obj.Key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	return m.verifyTarget(ctx, obj.Key, int64(len(data)), hash)
+}
+
+// verifyTarget перечитывает объект из цели и сверяет размер и SHA-256
+func (m *StorageMigrator) verifyTarget(ctx context.Context, key string, size int64, hash string) error {
+	info, err := m.target.Stat(ctx, key)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	if info.Size != size {
+		return fmt.Errorf("target size %d does not match source size %d", info.Size, size)
+	}
+
+	r, err := m.target.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	defer r.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("target: %w", err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != hash {
+		return errors.New("target checksum does not match source")
+	}
+	return nil
+}
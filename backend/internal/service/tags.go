@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxTagsPerImage   = 20
+	maxTagLength      = 50
+	maxTagSuggestions = 50
+)
+
+var ErrInvalidTags = errors.New("invalid tags")
+
+// ParseTags разбирает список тегов через запятую (поле формы, метаданные
+// tus, параметр запроса). Теги проверяются при сохранении.
+func ParseTags(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+// normalizeTags приводит теги к хранимому виду: нижний регистр, одиночные
+// пробелы, без повторов, по алфавиту. Пустые теги пропускаются.
+func normalizeTags(tags []string) ([]string, error) {
+	var result []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		if tag == "" || slices.Contains(result, tag) {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLength {
+			return nil, fmt.Errorf("%w: tag must be at most %d characters", ErrInvalidTags, maxTagLength)
+		}
+		// Запятая разделяет теги в формах и запросах
+		if strings.ContainsFunc(tag, func(r rune) bool { return r == ',' || unicode.IsControl(r) }) {
+			return nil, fmt.Errorf("%w: tag must not contain commas or control characters", ErrInvalidTags)
+		}
+		result = append(result, tag)
+	}
+	if len(result) > maxTagsPerImage {
+		return nil, fmt.Errorf("%w: at most %d tags per image", ErrInvalidTags, maxTagsPerImage)
+	}
+
+	slices.Sort(result)
+	return result, nil
+}
+
+// SetTags заменяет теги изображения
+func (s *ImageService) SetTags(image *models.Image, tags []string) error {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.SetTags(image.ID, tags); err != nil {
+		return err
+	}
+	image.Tags = tags
+	if image.Tags == nil {
+		image.Tags = []string{}
+	}
+	return nil
+}
+
+// addTags добавляет теги к уже имеющимся у изображения. Вместе с ними
+// тегов должно остаться не больше maxTagsPerImage, иначе ничего не
+// добавляется и возвращается ErrInvalidTags.
+func (s *ImageService) addTags(image *models.Image, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	existing, err := s.repo.GetTags([]string{image.ID})
+	if err != nil {
+		return err
+	}
+	if _, err := normalizeTags(append(existing[image.ID], tags...)); err != nil {
+		return err
+	}
+	return s.repo.AddTags(image.ID, tags)
+}
+
+// NewImageFilter проверяет теги фильтра списка изображений. matchAll
+// требует у изображения все теги, иначе достаточно одного.
+func NewImageFilter(tags []string, matchAll bool) (models.ImageFilter, error) {
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return models.ImageFilter{}, err
+	}
+	return models.ImageFilter{Tags: tags, AllTags: matchAll}, nil
+}
+
+// TagCounts возвращает все теги пользователя с числом изображений
+func (s *ImageService) TagCounts(userID string) ([]*models.TagCount, error) {
+	return s.repo.TagCounts(userID, "", 0)
+}
+
+// SuggestTags возвращает до limit тегов пользователя, начинающихся с prefix,
+// начиная с самых частых
+func (s *ImageService) SuggestTags(userID, prefix string, limit int) ([]*models.TagCount, error) {
+	if limit <= 0 || limit > maxTagSuggestions {
+		limit = maxTagSuggestions
+	}
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	return s.repo.TagCounts(userID, prefix, limit)
+}
+
+// attachTags заполняет Tags у изображений; у изображений без тегов — пустой список
+func (s *ImageService) attachTags(images []*models.Image) error {
+	ids := make([]string, len(images))
+	for i, image := range images {
+		ids[i] = image.ID
+	}
+
+	tags, err := s.repo.GetTags(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		image.Tags = tags[image.ID]
+		if image.Tags == nil {
+			image.Tags = []string{}
+		}
+	}
+	return nil
+}
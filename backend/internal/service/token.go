@@ -0,0 +1,144 @@
+package service
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+	"strings"
+	"time"
+)
+
+const (
+	// tokenPrefix помогает узнать токен в логах и секрет-сканерах
+	tokenPrefix = "iu_"
+
+	maxTokenNameLength = 100
+	maxTokenTTLDays    = 365
+)
+
+var ErrTokenNotFound = errors.New("token not found")
+
+func (s *AuthService) CreateToken(userID string, req models.CreateTokenRequest) (string, *models.APIToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > maxTokenNameLength {
+		return "", nil, fmt.Errorf("token name must be 1 to %d characters", maxTokenNameLength)
+	}
+
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxTokenTTLDays {
+		return "", nil, fmt.Errorf("expires_in_days must be between 0 and %d", maxTokenTTLDays)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, errors.New("failed to generate token")
+	}
+	secret := hex.EncodeToString(b)
+	plain := tokenPrefix + secret
+
+	token := &models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashSecret(plain),
+		Prefix:    tokenPrefix + secret[:8],
+		Scopes:    scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.tokenRepo.Create(token); err != nil {
+		return "", nil, errors.New("failed to create token")
+	}
+
+	return plain, token, nil
+}
+
+func (s *AuthService) ListTokens(userID string) ([]*models.APIToken, error) {
+	return s.tokenRepo.GetByUserID(userID)
+}
+
+func (s *AuthService) RevokeToken(userID, tokenID string) error {
+	err := s.tokenRepo.Revoke(tokenID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTokenNotFound
+	}
+	return err
+}
+
+// ValidateToken проверяет Bearer токен и возвращает его владельца
+func (s *AuthService) ValidateToken(plain string) (*models.User, *models.APIToken, error) {
+	if !strings.HasPrefix(plain, tokenPrefix) {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	token, err := s.tokenRepo.GetByHash(hashSecret(plain))
+	if err != nil {
+		return nil, nil, errors.New("invalid token")
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil {
+		return nil, nil, errors.New("token revoked")
+	}
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		return nil, nil, errors.New("token expired")
+	}
+
+	user, err := s.userRepo.GetByID(token.UserID)
+	if err != nil {
+		return nil, nil, errors.New("user not found")
+	}
+	if user.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
+	}
+	// Токены отзываются при сбросе пароля, но токен мог быть создан
+	// одновременно с ним
+	if user.PasswordResetRequired {
+		return nil, nil, ErrPasswordResetRequired
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > lastSeenInterval {
+		s.tokenRepo.UpdateLastUsed(token.ID, now)
+		token.LastUsedAt = &now
+	}
+
+	// Не возвращаем хеш пароля
+	user.PasswordHash = ""
+	return user, token, nil
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, scope := range scopes {
+		known := false
+		for _, allowed := range models.TokenScopes {
+			if scope == allowed {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			result = append(result, scope)
+		}
+	}
+
+	return result, nil
+}
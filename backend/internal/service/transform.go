@@ -0,0 +1,410 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	stdimage "image"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/storage"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// Режимы вписывания
+const (
+	FitContain = "contain" // Вписать в рамку с сохранением пропорций
+	FitCover   = "cover"   // Заполнить рамку с сохранением пропорций, лишнее обрезать
+	FitFill    = "fill"    // Растянуть точно до размеров рамки
+)
+
+// Точки привязки при обрезке в режиме cover
+var gravities = map[string][2]float64{
+	"center":    {0.5, 0.5},
+	"north":     {0.5, 0},
+	"south":     {0.5, 1},
+	"west":      {0, 0.5},
+	"east":      {1, 0.5},
+	"northwest": {0, 0},
+	"northeast": {1, 0},
+	"southwest": {0, 1},
+	"southeast": {1, 1},
+}
+
+type outputFormat struct {
+	mimeType  string
+	extension string
+}
+
+var outputFormats = map[string]outputFormat{
+	"jpeg": {"image/jpeg", ".jpg"},
+	"jpg":  {"image/jpeg", ".jpg"},
+	"png":  {"image/png", ".png"},
+}
+
+var ErrInvalidTransform = errors.New("invalid transform parameters")
+
+type TransformOptions struct {
+	Width   int
+	Height  int
+	Fit     string
+	Gravity string
+	Quality int
+	Format  string // jpeg или png; пусто — по формату источника
+}
+
+// TransformService изменяет размер изображений по запросу и хранит
+// результаты в локальном дисковом кэше с ограничением по объему
+// независимо от того, где лежат оригиналы
+type TransformService struct {
+	storage storage.Storage
+	config  *config.Config
+	allowed map[int]bool
+	slots   chan struct{}
+
+	mu        sync.Mutex
+	cacheSize int64
+	inflight  map[string]*transformCall
+}
+
+type transformCall struct {
+	done chan struct{}
+	data []byte // Результат, если запись кэша вытеснят до того, как ее откроют
+	err  error
+}
+
+// Transformed — результат преобразования для отдачи клиенту: открытый файл
+// кэша или, если запись уже вытеснена, содержимое в памяти. Открытый файл
+// остается читаемым, даже если вытеснение удалит его.
+type Transformed struct {
+	Content  io.ReadSeeker
+	ModTime  time.Time
+	MimeType string
+
+	file *os.File
+}
+
+// Close закрывает файл кэша
+func (t *Transformed) Close() error {
+	if t.file == nil {
+		return nil
+	}
+	return t.file.Close()
+}
+
+func NewTransformService(store storage.Storage, cfg *config.Config) *TransformService {
+	os.MkdirAll(cfg.TransformCacheDir, 0755)
+
+	allowed := make(map[int]bool)
+	for _, size := range cfg.TransformSizes {
+		allowed[size] = true
+	}
+
+	s := &TransformService{
+		storage:  store,
+		config:   cfg,
+		allowed:  allowed,
+		slots:    make(chan struct{}, max(1, cfg.TransformConcurrency)),
+		inflight: make(map[string]*transformCall),
+	}
+	s.cacheSize = s.scanCacheSize()
+	return s
+}
+
+// Validate проверяет параметры и подставляет значения по умолчанию.
+// Размеры допускаются только из списка TransformSizes.
+func (s *TransformService) Validate(opts *TransformOptions) error {
+	if opts.Width == 0 && opts.Height == 0 && opts.Format == "" {
+		return fmt.Errorf("%w: at least one of w, h or fmt is required", ErrInvalidTransform)
+	}
+	if opts.Width != 0 && !s.allowed[opts.Width] {
+		return fmt.Errorf("%w: width %d is not allowed", ErrInvalidTransform, opts.Width)
+	}
+	if opts.Height != 0 && !s.allowed[opts.Height] {
+		return fmt.Errorf("%w: height %d is not allowed", ErrInvalidTransform, opts.Height)
+	}
+
+	if opts.Fit == "" {
+		opts.Fit = FitContain
+	}
+	if opts.Fit != FitContain && opts.Fit != FitCover && opts.Fit != FitFill {
+		return fmt.Errorf("%w: unknown fit %q", ErrInvalidTransform, opts.Fit)
+	}
+
+	if opts.Gravity == "" {
+		opts.Gravity = "center"
+	}
+	if _, ok := gravities[opts.Gravity]; !ok {
+		return fmt.Errorf("%w: unknown gravity %q", ErrInvalidTransform, opts.Gravity)
+	}
+
+	if opts.Quality == 0 {
+		opts.Quality = variantJPEGQuality
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		return fmt.Errorf("%w: quality must be between 1 and 100", ErrInvalidTransform)
+	}
+
+	if opts.Format != "" {
+		if _, ok := outputFormats[opts.Format]; !ok {
+			return fmt.Errorf("%w: unsupported format %q", ErrInvalidTransform, opts.Format)
+		}
+	}
+
+	return nil
+}
+
+// Transform преобразует объект хранилища srcKey. Результат нужно закрыть.
+// Одинаковые запросы, пришедшие одновременно, обрабатываются один раз.
+func (s *TransformService) Transform(srcKey, srcMimeType string, opts TransformOptions) (*Transformed, error) {
+	mimeType, ext := variantFormat(srcMimeType)
+	if format, ok := outputFormats[opts.Format]; ok {
+		mimeType, ext = format.mimeType, format.extension
+	}
+
+	key := transformKey(srcKey, mimeType, opts)
+	cachePath := filepath.Join(s.config.TransformCacheDir, key[:2], key+ext)
+
+	if result, err := openCached(cachePath, mimeType); err == nil {
+		// Обновляем время изменения — по нему вытесняются старые записи
+		now := time.Now()
+		os.Chtimes(cachePath, now, now)
+		return result, nil
+	}
+
+	s.mu.Lock()
+	call, ok := s.inflight[key]
+	if ok {
+		s.mu.Unlock()
+		<-call.done
+	} else {
+		call = &transformCall{done: make(chan struct{})}
+		s.inflight[key] = call
+		s.mu.Unlock()
+
+		call.data, call.err = s.render(srcKey, cachePath, mimeType, opts)
+
+		s.mu.Lock()
+		delete(s.inflight, key)
+		s.mu.Unlock()
+		close(call.done)
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	result, err := openCached(cachePath, mimeType)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Запись успели вытеснить; отдаем то, что только что получили
+		return &Transformed{Content: bytes.NewReader(call.data), ModTime: time.Now(), MimeType: mimeType}, nil
+	}
+	return result, err
+}
+
+// openCached открывает запись кэша
+func openCached(cachePath, mimeType string) (*Transformed, error) {
+	f, err := os.Open(cachePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Transformed{Content: f, ModTime: info.ModTime(), MimeType: mimeType, file: f}, nil
+}
+
+// render записывает результат в кэш и возвращает его. Не зависит от
+// контекста запроса: его результат ждут все одинаковые запросы, и отмена
+// первого из них не должна их прерывать.
+func (s *TransformService) render(srcKey, cachePath, mimeType string, opts TransformOptions) ([]byte, error) {
+	// Ограничиваем число одновременных преобразований, чтобы не занять весь CPU
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	data, err := readObject(context.Background(), s.storage, srcKey)
+	if err != nil {
+		return nil, err
+	}
+	src, err := decodeImage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	dst := resize(src, opts)
+
+	var buf bytes.Buffer
+	if err := encodeImage(&buf, dst, mimeType, opts.Quality); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return nil, err
+	}
+
+	// Пишем во временный файл и переименовываем, чтобы читатели не увидели половину
+	tmp := cachePath + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, cachePath); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+
+	s.addToCache(int64(buf.Len()))
+	return buf.Bytes(), nil
+}
+
+// resize применяет режим вписывания. Изображение не увеличивается сверх
+// исходного размера в режимах contain и cover.
+func resize(src stdimage.Image, opts TransformOptions) stdimage.Image {
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	w, h := opts.Width, opts.Height
+
+	// Без одной из сторон cover и fill не определены — вписываем по заданной
+	fit := opts.Fit
+	if w == 0 || h == 0 {
+		fit = FitContain
+	}
+
+	switch fit {
+	case FitFill:
+		return scale(src, src.Bounds(), w, h)
+
+	case FitCover:
+		ratio := max(float64(w)/float64(srcW), float64(h)/float64(srcH))
+		if ratio > 1 {
+			// Не увеличиваем: пропорционально уменьшаем рамку до размеров источника
+			w = int(float64(w) / ratio)
+			h = int(float64(h) / ratio)
+			ratio = 1
+		}
+		// Область источника, которая после масштабирования займет рамку
+		cropW := min(srcW, int(float64(w)/ratio+0.5))
+		cropH := min(srcH, int(float64(h)/ratio+0.5))
+		g := gravities[opts.Gravity]
+		x0 := src.Bounds().Min.X + int(float64(srcW-cropW)*g[0])
+		y0 := src.Bounds().Min.Y + int(float64(srcH-cropH)*g[1])
+		return scale(src, stdimage.Rect(x0, y0, x0+cropW, y0+cropH), max(1, w), max(1, h))
+
+	default:
+		if w == 0 && h == 0 {
+			return src
+		}
+		ratio := 1.0
+		if w != 0 {
+			ratio = min(ratio, float64(w)/float64(srcW))
+		}
+		if h != 0 {
+			ratio = min(ratio, float64(h)/float64(srcH))
+		}
+		if ratio == 1 {
+			return src
+		}
+		return scale(src, src.Bounds(), max(1, int(float64(srcW)*ratio+0.5)), max(1, int(float64(srcH)*ratio+0.5)))
+	}
+}
+
+func scale(src stdimage.Image, srcRect stdimage.Rectangle, w, h int) stdimage.Image {
+	dst := stdimage.NewNRGBA(stdimage.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Src, nil)
+	return dst
+}
+
+// readObject читает объект хранилища целиком
+func readObject(ctx context.Context, store storage.Storage, key string) ([]byte, error) {
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func transformKey(srcKey, mimeType string, opts TransformOptions) string {
+	raw := fmt.Sprintf("%s|w=%d|h=%d|fit=%s|g=%s|q=%d|mime=%s",
+		srcKey, opts.Width, opts.Height, opts.Fit, opts.Gravity, opts.Quality, mimeType)
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *TransformService) scanCacheSize() int64 {
+	var total int64
+	filepath.WalkDir(s.config.TransformCacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
+
+// addToCache учитывает новый файл и при превышении лимита удаляет
+// давно не использованные записи, пока кэш не сократится до 90% лимита
+func (s *TransformService) addToCache(size int64) {
+	s.mu.Lock()
+	s.cacheSize += size
+	over := s.config.TransformCacheMaxBytes > 0 && s.cacheSize > s.config.TransformCacheMaxBytes
+	s.mu.Unlock()
+
+	if over {
+		s.evict()
+	}
+}
+
+func (s *TransformService) evict() {
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var entries []entry
+	var total int64
+	filepath.WalkDir(s.config.TransformCacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+			total += info.Size()
+		}
+		return nil
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	target := s.config.TransformCacheMaxBytes * 9 / 10
+	for _, e := range entries {
+		if total <= target {
+			break
+		}
+		if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to evict transform cache entry %s: %v", e.path, err)
+			continue
+		}
+		total -= e.size
+	}
+
+	s.mu.Lock()
+	s.cacheSize = total
+	s.mu.Unlock()
+}
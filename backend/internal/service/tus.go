@@ -0,0 +1,396 @@
+package service
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"image-uploader-backend/internal/config"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTusUploadNotFound      = errors.New("upload not found")
+	ErrTusInvalidLength       = errors.New("upload length must be a positive number")
+	ErrTusTooLarge            = errors.New("upload exceeds maximum allowed size")
+	ErrTusOffsetMismatch      = errors.New("upload offset does not match")
+	ErrTusExceedsLength       = errors.New("chunk exceeds upload length")
+	ErrTusInvalidChecksum     = errors.New("invalid Upload-Checksum header")
+	ErrTusUnsupportedChecksum = errors.New("unsupported checksum algorithm")
+	ErrTusChecksumMismatch    = errors.New("checksum mismatch")
+	ErrTusLocked              = errors.New("upload is being written by another request")
+	ErrTusInvalidImage        = errors.New("uploaded file is not a valid image")
+)
+
+// tusChecksums — алгоритмы расширения checksum
+var tusChecksums = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// TusChecksumAlgorithms возвращает поддерживаемые алгоритмы для Tus-Checksum-Algorithm
+func TusChecksumAlgorithms() []string {
+	names := make([]string, 0, len(tusChecksums))
+	for name := range tusChecksums {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TusService принимает загрузки по частям (протокол tus 1.0). Данные копятся
+// в UPLOAD_DIR/.tus, а после получения последнего байта файл проходит
+// обычную проверку и сохранение ImageService.
+type TusService struct {
+	repo   *repository.TusRepository
+	images *ImageService
+	quotas *QuotaService
+	config *config.Config
+	dir    string
+
+	mu   sync.Mutex
+	busy map[string]bool
+}
+
+func NewTusService(repo *repository.TusRepository, images *ImageService, quotas *QuotaService, cfg *config.Config) *TusService {
+	dir := filepath.Join(cfg.UploadDir, ".tus")
+	os.MkdirAll(dir, 0755)
+
+	return &TusService{
+		repo:   repo,
+		images: images,
+		quotas: quotas,
+		config: cfg,
+		dir:    dir,
+		busy:   make(map[string]bool),
+	}
+}
+
+// Create регистрирует новую загрузку и создает пустой файл для ее данных.
+// Пустая visibility означает видимость по умолчанию, пустой albumID — без альбома.
+func (s *TusService) Create(userID string, length int64, filename, filetype, visibility, albumID string, tags []string, caption string) (*models.TusUpload, error) {
+	if length <= 0 {
+		return nil, ErrTusInvalidLength
+	}
+	// Видимость проверяется сразу, чтобы не принимать файл, который нельзя сохранить
+	if visibility != "" && !models.ValidVisibility(visibility) {
+		return nil, ErrInvalidVisibility
+	}
+	if length > s.config.MaxFileSize {
+		return nil, ErrTusTooLarge
+	}
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	caption, err = normalizeCaption(caption)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.images.CheckAlbum(albumID, userID); err != nil {
+		return nil, err
+	}
+	// Файл, который не поместится в квоту, не стоит и принимать
+	if _, err := s.quotas.Check(userID, length); err != nil {
+		return nil, err
+	}
+
+	// Брошенные загрузки чистим при создании новых
+	if err := s.PurgeExpired(); err != nil {
+		log.Printf("Failed to purge expired uploads: %v", err)
+	}
+
+	upload := &models.TusUpload{
+		UserID:     userID,
+		Length:     length,
+		Filename:   filename,
+		Filetype:   filetype,
+		Visibility: visibility,
+		AlbumID:    albumID,
+		Tags:       tags,
+		Caption:    caption,
+		ExpiresAt:  time.Now().Add(s.config.TusUploadTTL),
+	}
+	if err := s.repo.Create(upload); err != nil {
+		return nil, err
+	}
+
+	f, err := os.Create(s.dataPath(upload.ID))
+	if err != nil {
+		s.repo.Delete(upload.ID)
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	f.Close()
+
+	return upload, nil
+}
+
+// Get возвращает загрузку пользователя. Чужие и просроченные загрузки
+// выглядят так же, как несуществующие.
+func (s *TusService) Get(id, userID string) (*models.TusUpload, error) {
+	upload, err := s.repo.GetByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTusUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if upload.UserID != userID || time.Now().After(upload.ExpiresAt) {
+		return nil, ErrTusUploadNotFound
+	}
+	return upload, nil
+}
+
+// WriteChunk дописывает часть данных с позиции offset. checksum — значение
+// заголовка Upload-Checksum или пустая строка. Без контрольной суммы
+// полученные до обрыва соединения байты сохраняются; с ней часть
+// принимается только целиком. Когда получен последний байт, загрузка
+// собирается в изображение и оно возвращается вместе с загрузкой.
+func (s *TusService) WriteChunk(upload *models.TusUpload, offset int64, body io.Reader, checksum string) (*models.TusUpload, *models.Image, error) {
+	var hasher hash.Hash
+	var expected []byte
+	if checksum != "" {
+		var err error
+		hasher, expected, err = parseChecksum(checksum)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !s.lock(upload.ID) {
+		return nil, nil, ErrTusLocked
+	}
+	defer s.unlock(upload.ID)
+
+	// Перечитываем состояние под блокировкой: смещение могло измениться
+	upload, err := s.Get(upload.ID, upload.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if offset != upload.Offset || upload.ImageID != "" {
+		return nil, nil, ErrTusOffsetMismatch
+	}
+
+	// Все байты уже получены, но сохранить изображение не удалось —
+	// пустой PATCH с конечным смещением повторяет сборку
+	if !upload.Complete() {
+		if err := s.write(upload, body, hasher, expected); err != nil {
+			return nil, nil, err
+		}
+		if !upload.Complete() {
+			return upload, nil, nil
+		}
+	}
+
+	image, err := s.finish(upload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return upload, image, nil
+}
+
+// write дописывает данные в файл загрузки и сохраняет новое смещение
+func (s *TusService) write(upload *models.TusUpload, body io.Reader, hasher hash.Hash, expected []byte) error {
+	f, err := os.OpenFile(s.dataPath(upload.ID), os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer f.Close()
+
+	// Отрезаем хвост, оставшийся от прерванной записи, которая не попала в базу
+	if err := f.Truncate(upload.Offset); err != nil {
+		return err
+	}
+	if _, err := f.Seek(upload.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	remaining := upload.Length - upload.Offset
+	var w io.Writer = f
+	if hasher != nil {
+		w = io.MultiWriter(f, hasher)
+	}
+	// Читаем на байт больше остатка, чтобы заметить лишние данные
+	n, copyErr := io.Copy(w, io.LimitReader(body, remaining+1))
+
+	if n > remaining {
+		f.Truncate(upload.Offset)
+		return ErrTusExceedsLength
+	}
+	if hasher != nil {
+		if copyErr != nil {
+			f.Truncate(upload.Offset)
+			return copyErr
+		}
+		if !bytes.Equal(hasher.Sum(nil), expected) {
+			f.Truncate(upload.Offset)
+			return ErrTusChecksumMismatch
+		}
+	}
+
+	if n > 0 {
+		upload.Offset += n
+		upload.ExpiresAt = time.Now().Add(s.config.TusUploadTTL)
+		if err := s.repo.UpdateOffset(upload.ID, upload.Offset, upload.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	return copyErr
+}
+
+// finish проверяет собранный файл и сохраняет его как обычное изображение.
+// Файл, не прошедший проверку, удаляется вместе с загрузкой: повторная
+// отправка тех же байтов ничего не изменит.
+func (s *TusService) finish(upload *models.TusUpload) (*models.Image, error) {
+	path := s.dataPath(upload.ID)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer f.Close()
+
+	info, err := s.images.ValidateContent(f, upload.Length, upload.Filetype)
+	if err != nil {
+		s.remove(upload.ID)
+		return nil, fmt.Errorf("%w: %v", ErrTusInvalidImage, err)
+	}
+
+	// Альбом могли удалить, пока шла загрузка; файл от этого не теряется
+	albumID := upload.AlbumID
+	if err := s.images.CheckAlbum(albumID, upload.UserID); err != nil {
+		log.Printf("Upload %s: album %s is unavailable: %v", upload.ID, albumID, err)
+		albumID = ""
+	}
+	image, _, err := s.images.SaveContent(f, upload.Length, upload.Filename, info, upload.UserID, SaveOptions{
+		Visibility: upload.Visibility,
+		AlbumID:    albumID,
+		Tags:       upload.Tags,
+		Caption:    upload.Caption,
+	})
+	if errors.Is(err, ErrQuotaExceeded) {
+		// Квота заполнилась, пока шла загрузка; повторная отправка не поможет
+		s.remove(upload.ID)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Запись о загрузке остается до истечения срока, чтобы HEAD сообщал о завершении
+	upload.ImageID = image.ID
+	if err := s.repo.Complete(upload.ID, image.ID); err != nil {
+		log.Printf("Failed to mark upload %s complete: %v", upload.ID, err)
+	}
+	if err := os.Remove(path); err != nil {
+		log.Printf("Failed to remove upload file %s: %v", path, err)
+	}
+
+	return image, nil
+}
+
+// RemoveUserUploads удаляет все загрузки пользователя вместе с данными
+func (s *TusService) RemoveUserUploads(userID string) error {
+	ids, err := s.repo.DeleteByUserID(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := os.Remove(s.dataPath(id)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove upload %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// Terminate прерывает загрузку и удаляет ее данные (расширение termination)
+func (s *TusService) Terminate(upload *models.TusUpload) error {
+	if !s.lock(upload.ID) {
+		return ErrTusLocked
+	}
+	defer s.unlock(upload.ID)
+
+	return s.remove(upload.ID)
+}
+
+// PurgeExpired удаляет брошенные загрузки с истекшим сроком
+func (s *TusService) PurgeExpired() error {
+	ids, err := s.repo.DeleteExpired(time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := os.Remove(s.dataPath(id)); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove expired upload %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+func (s *TusService) remove(id string) error {
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	if err := os.Remove(s.dataPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *TusService) dataPath(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// lock не дает двум запросам одновременно писать в одну загрузку
+func (s *TusService) lock(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.busy[id] {
+		return false
+	}
+	s.busy[id] = true
+	return true
+}
+
+func (s *TusService) unlock(id string) {
+	s.mu.Lock()
+	delete(s.busy, id)
+	s.mu.Unlock()
+}
+
+// parseChecksum разбирает заголовок Upload-Checksum вида "<алгоритм> <base64>"
+func parseChecksum(header string) (hash.Hash, []byte, error) {
+	name, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, nil, ErrTusInvalidChecksum
+	}
+
+	newHash, ok := tusChecksums[name]
+	if !ok {
+		return nil, nil, ErrTusUnsupportedChecksum
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, ErrTusInvalidChecksum
+	}
+	return newHash(), expected, nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/repository"
+	"log"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 50
+	minPasswordLength = 6
+
+	// Сколько раз удаление пользователя повторяется, если изображения
+	// появились во время удаления
+	maxDeleteAttempts = 3
+)
+
+var (
+	ErrUserNotFound     = repository.ErrUserNotFound
+	ErrInvalidUser      = errors.New("invalid user")
+	ErrSelfModification = errors.New("admins cannot disable, delete or demote their own account")
+	ErrUserHasImages    = repository.ErrUserHasImages
+)
+
+// UserService — управление пользователями администратором: создание, роль,
+// сброс пароля, отключение и удаление
+type UserService struct {
+	repo     *repository.UserRepository
+	sessions repository.SessionStore
+	images   *ImageService
+	tus      *TusService
+}
+
+func NewUserService(repo *repository.UserRepository, sessions repository.SessionStore, images *ImageService, tus *TusService) *UserService {
+	return &UserService{
+		repo:     repo,
+		sessions: sessions,
+		images:   images,
+		tus:      tus,
+	}
+}
+
+// Get возвращает пользователя без хеша пароля
+func (s *UserService) Get(id string) (*models.User, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// Create создает пользователя с любой ролью независимо от режима регистрации
+func (s *UserService) Create(req models.CreateUserRequest) (*models.User, error) {
+	if len(req.Username) < minUsernameLength || len(req.Username) > maxUsernameLength {
+		return nil, fmt.Errorf("%w: username must be between %d and %d characters", ErrInvalidUser, minUsernameLength, maxUsernameLength)
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	role := req.Role
+	if role == "" {
+		role = models.RoleUser
+	}
+	if err := validateRole(role); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, errors.New("failed to hash password")
+	}
+
+	user := &models.User{
+		Username:              req.Username,
+		PasswordHash:          string(hashedPassword),
+		Role:                  role,
+		PasswordResetRequired: req.PasswordResetRequired,
+	}
+	if err := s.repo.Create(user); err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrUsernameExists
+		}
+		return nil, err
+	}
+
+	user.PasswordHash = ""
+	return user, nil
+}
+
+// Update меняет роль и включает или отключает учетную запись. Отключение
+// сразу завершает все сессии пользователя. Свою учетную запись
+// администратор не может отключить или лишить роли admin.
+func (s *UserService) Update(actor *models.User, id string, req models.UpdateUserRequest) (*models.User, error) {
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Role != nil {
+		if err := validateRole(*req.Role); err != nil {
+			return nil, err
+		}
+		if user.ID == actor.ID && *req.Role != models.RoleAdmin {
+			return nil, ErrSelfModification
+		}
+	}
+	if req.Disabled != nil && *req.Disabled && user.ID == actor.ID {
+		return nil, ErrSelfModification
+	}
+
+	if req.Role != nil && *req.Role != user.Role {
+		if err := s.repo.UpdateRole(user.ID, *req.Role); err != nil {
+			return nil, err
+		}
+	}
+	if req.Disabled != nil {
+		switch {
+		case *req.Disabled && user.DisabledAt == nil:
+			if err := s.disable(user.ID); err != nil {
+				return nil, err
+			}
+		case !*req.Disabled && user.DisabledAt != nil:
+			if err := s.repo.SetDisabled(user.ID, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.Get(user.ID)
+}
+
+// ResetPassword задает пользователю новый пароль (пустой — генерируется
+// временный) и требует сменить его перед следующим входом. Сессии
+// пользователя завершаются. Возвращает пароль, который нужно ему передать.
+func (s *UserService) ResetPassword(id, password string) (string, error) {
+	if password == "" {
+		generated, err := generatePassword()
+		if err != nil {
+			return "", err
+		}
+		password = generated
+	}
+	if err := validatePassword(password); err != nil {
+		return "", err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", errors.New("failed to hash password")
+	}
+	if err := s.repo.ResetPassword(id, string(hashedPassword)); err != nil {
+		return "", err
+	}
+	// Пока пароль не сменен, сессии не принимаются, поэтому после ошибки
+	// удаления сессий временный пароль все равно нужно вернуть
+	if err := s.sessions.DeleteByUserID(id); err != nil {
+		log.Printf("Failed to delete sessions of user %s after password reset: %v", id, err)
+	}
+
+	return password, nil
+}
+
+// Delete удаляет пользователя. Его изображения удаляются вместе с файлами
+// (images=delete) или передаются пользователю reassignTo (images=reassign);
+// если изображения есть, а способ не выбран, возвращается ErrUserHasImages.
+// Перед тем как перечислить изображения, учетная запись отключается, чтобы
+// новые не появлялись. Загрузка, начатая до отключения, может завершиться
+// позже: тогда при images=delete удаление повторяется, а без способа
+// удаление отменяется и учетная запись снова включается.
+func (s *UserService) Delete(actor *models.User, id, images, reassignTo string) error {
+	if id == actor.ID {
+		return ErrSelfModification
+	}
+	user, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	switch images {
+	case "", models.UserImagesDelete:
+		reassignTo = ""
+	case models.UserImagesReassign:
+		if reassignTo == "" || reassignTo == user.ID {
+			return fmt.Errorf("%w: reassign_to must be another user", ErrInvalidUser)
+		}
+		if _, err := s.repo.GetByID(reassignTo); err != nil {
+			if errors.Is(err, ErrUserNotFound) {
+				return fmt.Errorf("%w: reassign_to user not found", ErrInvalidUser)
+			}
+			return err
+		}
+	default:
+		return fmt.Errorf("%w: images must be %s or %s", ErrInvalidUser, models.UserImagesDelete, models.UserImagesReassign)
+	}
+
+	// Без способа удаления проверяем заранее, чтобы отказ не отключал учетную запись
+	if images == "" {
+		imageIDs, err := s.images.IDsByUser(user.ID)
+		if err != nil {
+			return err
+		}
+		if len(imageIDs) > 0 {
+			return ErrUserHasImages
+		}
+	}
+
+	if user.DisabledAt == nil {
+		if err := s.disable(user.ID); err != nil {
+			return err
+		}
+	}
+	if err := s.tus.RemoveUserUploads(user.ID); err != nil {
+		return err
+	}
+
+	count := 0
+	for attempt := 1; ; attempt++ {
+		imageIDs, err := s.images.IDsByUser(user.ID)
+		if err != nil {
+			return err
+		}
+		if images == models.UserImagesDelete {
+			if err := s.deleteImages(imageIDs); err != nil {
+				return err
+			}
+		}
+		count += len(imageIDs)
+
+		err = s.repo.Delete(user.ID, reassignTo)
+		if errors.Is(err, ErrUserHasImages) && images == models.UserImagesDelete && attempt < maxDeleteAttempts {
+			continue
+		}
+		if errors.Is(err, ErrUserHasImages) && user.DisabledAt == nil {
+			if err := s.repo.SetDisabled(user.ID, nil); err != nil {
+				log.Printf("Failed to re-enable user %s after cancelled deletion: %v", user.ID, err)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		break
+	}
+
+	log.Printf("Deleted user %s with %d images (%s)", user.Username, count, imagesAction(images))
+	return nil
+}
+
+// deleteImages удаляет изображения вместе с файлами; уже удаленные пропускаются
+func (s *UserService) deleteImages(imageIDs []string) error {
+	for _, imageID := range imageIDs {
+		image, err := s.images.Lookup(imageID)
+		if errors.Is(err, ErrImageNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.images.Delete(image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disable отключает учетную запись и завершает ее сессии
+func (s *UserService) disable(id string) error {
+	now := time.Now()
+	if err := s.repo.SetDisabled(id, &now); err != nil {
+		return err
+	}
+	return s.sessions.DeleteByUserID(id)
+}
+
+func imagesAction(images string) string {
+	if images == models.UserImagesReassign {
+		return "reassigned"
+	}
+	return "deleted"
+}
+
+func validateRole(role string) error {
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return fmt.Errorf("%w: role must be %s or %s", ErrInvalidUser, models.RoleUser, models.RoleAdmin)
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidUser, minPasswordLength)
+	}
+	return nil
+}
+
+// generatePassword создает временный пароль из 16 символов base64url
+func generatePassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New("failed to generate password")
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	stdimage "image"
+	"image-uploader-backend/internal/metadata"
+	"image-uploader-backend/internal/models"
+	"image-uploader-backend/internal/storage"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+const variantJPEGQuality = 85
+
+// generateVariants создает уменьшенные копии сохраненного изображения рядом
+// с оригиналом (r — его содержимое размером size) и записывает их в
+// image_variants. Варианты, которые не меньше оригинала, не создаются —
+// вместо них отдается URL оригинала. Файлы копий, как и оригинал, общие для
+// изображений с одинаковым содержимым: уже существующая копия используется
+// повторно.
+func (s *ImageService) generateVariants(image *models.Image, r io.ReaderAt, size int64) error {
+	if len(s.config.Variants) == 0 {
+		return nil
+	}
+
+	src, err := decodeImage(r, size)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	dir := path.Dir(image.StorageKey)
+	base := strings.TrimSuffix(image.FileName, path.Ext(image.FileName))
+	mimeType, ext := variantFormat(image.MimeType)
+
+	for _, spec := range s.config.Variants {
+		width, height, ok := fitWithin(src.Bounds().Dx(), src.Bounds().Dy(), spec.MaxSize)
+		if !ok {
+			continue
+		}
+
+		fileName := base + "_" + spec.Name + ext
+		key := dir + "/" + fileName
+
+		var size int64
+		if stat, err := s.storage.Stat(ctx, key); err == nil {
+			size = stat.Size
+		} else if errors.Is(err, storage.ErrNotFound) {
+			dst := stdimage.NewNRGBA(stdimage.Rect(0, 0, width, height))
+			draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+			size, err = putImage(ctx, s.storage, key, dst, mimeType, variantJPEGQuality)
+			if err != nil {
+				return fmt.Errorf("failed to write variant %s: %w", spec.Name, err)
+			}
+		} else {
+			return fmt.Errorf("failed to check variant %s: %w", spec.Name, err)
+		}
+
+		variant := &models.ImageVariant{
+			ImageID:    image.ID,
+			Name:       spec.Name,
+			FileName:   fileName,
+			StorageKey: key,
+			MimeType:   mimeType,
+			Width:      width,
+			Height:     height,
+			Size:       size,
+		}
+		if err := s.repo.CreateVariant(variant); err != nil {
+			// Файл не удаляем: он может принадлежать другому изображению
+			// и будет удален вместе с оригиналом
+			return fmt.Errorf("failed to save variant %s: %w", spec.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// attachVariants заполняет Variants у изображений: сохраненные копии и URL
+// оригинала для настроенных вариантов, которые не создавались
+func (s *ImageService) attachVariants(images []*models.Image) error {
+	ids := make([]string, len(images))
+	for i, image := range images {
+		ids[i] = image.ID
+	}
+
+	variants, err := s.repo.GetVariants(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, image := range images {
+		image.Variants = make(map[string]string)
+		for _, spec := range s.config.Variants {
+			image.Variants[spec.Name] = image.URL
+		}
+		for _, variant := range variants[image.ID] {
+			url, err := s.fileURL(image, variant.StorageKey)
+			if err != nil {
+				return err
+			}
+			image.Variants[variant.Name] = url
+		}
+	}
+
+	return nil
+}
+
+// variantFormat выбирает формат копии: JPEG остается JPEG, остальные
+// форматы кодируются в PNG, чтобы сохранить прозрачность
+func variantFormat(mimeType string) (string, string) {
+	if mimeType == "image/jpeg" {
+		return "image/jpeg", ".jpg"
+	}
+	return "image/png", ".png"
+}
+
+// fitWithin вписывает размеры в квадрат maxSize×maxSize с сохранением пропорций.
+// Возвращает false, если изображение уже помещается и уменьшать не нужно.
+func fitWithin(width, height, maxSize int) (int, int, bool) {
+	if width <= maxSize && height <= maxSize {
+		return width, height, false
+	}
+
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width), true
+	}
+	return max(1, width*maxSize/height), maxSize, true
+}
+
+// decodeImage декодирует изображение и применяет EXIF Orientation, если тег
+// остался в файле (WebP или AUTO_ROTATE=false): копии кодируются без EXIF,
+// поэтому поворот должен быть уже в пикселях
+func decodeImage(r io.ReaderAt, size int64) (stdimage.Image, error) {
+	img, format, err := stdimage.Decode(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return metadata.Orient(img, metadata.Extract(r, size, format).Orientation), nil
+}
+
+// putImage кодирует изображение и записывает его в хранилище, возвращая
+// размер. Хранилище гарантирует, что параллельная загрузка того же
+// содержимого не увидит недописанную копию.
+func putImage(ctx context.Context, store storage.Storage, key string, img stdimage.Image, mimeType string, quality int) (int64, error) {
+	var buf bytes.Buffer
+	if err := encodeImage(&buf, img, mimeType, quality); err != nil {
+		return 0, err
+	}
+
+	size := int64(buf.Len())
+	if err := store.Put(ctx, key, &buf, size, mimeType); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func encodeImage(w io.Writer, img stdimage.Image, mimeType string, quality int) error {
+	switch mimeType {
+	case "image/jpeg":
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	case "image/png":
+		return png.Encode(w, img)
+	default:
+		return fmt.Errorf("unsupported output format %s", mimeType)
+	}
+}
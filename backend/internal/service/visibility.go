@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"image-uploader-backend/internal/models"
+	"net/url"
+)
+
+var ErrInvalidVisibility = errors.New("visibility must be public, unlisted or private")
+
+// ResolveVisibility подставляет DEFAULT_VISIBILITY вместо пустого значения
+// и проверяет результат
+func (s *ImageService) ResolveVisibility(visibility string) (string, error) {
+	if visibility == "" {
+		visibility = s.config.DefaultVisibility
+	}
+	if !models.ValidVisibility(visibility) {
+		return "", ErrInvalidVisibility
+	}
+	return visibility, nil
+}
+
+// newAccessToken создает случайный токен для ссылок на unlisted изображения
+func newAccessToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// fileURL возвращает ссылку на файл изображения с учетом его видимости.
+// Публичные файлы отдаются напрямую из хранилища, остальные — только
+// через /images сервера, где проверяется доступ: хранилище (например,
+// S3_PUBLIC_URL) ничего не знает о видимости. Скрытое изображение
+// отдается как приватное.
+func (s *ImageService) fileURL(image *models.Image, key string) (string, error) {
+	switch {
+	case image.HiddenAt != nil, image.Visibility == models.VisibilityPrivate:
+		return s.config.BaseURL + "/images/" + key, nil
+	case image.Visibility == models.VisibilityUnlisted:
+		return s.config.BaseURL + "/images/" + key + "?t=" + url.QueryEscape(image.AccessToken), nil
+	default:
+		return s.storage.URL(context.Background(), key)
+	}
+}
+
+// SetVisibility меняет видимость изображения. При переводе в unlisted
+// создается новый токен, так что ссылки, выданные раньше, перестают работать.
+func (s *ImageService) SetVisibility(image *models.Image, visibility string) error {
+	if !models.ValidVisibility(visibility) {
+		return ErrInvalidVisibility
+	}
+
+	token := image.AccessToken
+	if visibility == models.VisibilityUnlisted && image.Visibility != models.VisibilityUnlisted {
+		var err error
+		if token, err = newAccessToken(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.UpdateVisibility(image.ID, visibility, token); err != nil {
+		return err
+	}
+	image.Visibility = visibility
+	image.AccessToken = token
+
+	return s.populate([]*models.Image{image})
+}
+
+// canView сообщает, может ли запрос получить файл: публичный — любой,
+// приватный — владелец, администратор и подписанная ссылка на этот файл,
+// unlisted — они же или по токену из ссылки. Скрытый администратором
+// файл — только владелец и администратор.
+func canView(file *models.StoredFile, access FileAccess) bool {
+	viewer := access.Viewer
+	if viewer != nil && (viewer.ID == file.UserID || viewer.Role == "admin") {
+		return true
+	}
+	if file.Hidden {
+		return false
+	}
+	if file.Visibility == models.VisibilityPublic {
+		return true
+	}
+	if access.Signed && access.SignedVariant == file.Variant {
+		return true
+	}
+	return file.Visibility == models.VisibilityUnlisted && access.Token != "" && access.Token == file.AccessToken
+}
@@ -0,0 +1,152 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Options — параметры подключения к S3-совместимому хранилищу
+// (AWS S3, MinIO, Ceph RGW и т.п.)
+type S3Options struct {
+	Endpoint  string // host[:port] без схемы
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool   // Адресовать бакет путем, а не поддоменом (нужно для MinIO)
+	Prefix    string // Общий префикс ключей внутри бакета
+
+	// PublicURL — адрес, по которому объекты бакета доступны без подписи
+	// (публичный бакет или CDN). Пустой — выдаются подписанные URL.
+	PublicURL  string
+	PresignTTL time.Duration
+}
+
+type S3 struct {
+	client *minio.Client
+	opts   S3Options
+}
+
+// NewS3 подключается к хранилищу и проверяет, что бакет существует
+func NewS3(opts S3Options) (*S3, error) {
+	lookup := minio.BucketLookupAuto
+	if opts.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:       opts.UseSSL,
+		Region:       opts.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, opts.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access bucket %s: %w", opts.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %s does not exist", opts.Bucket)
+	}
+
+	opts.Prefix = strings.Trim(opts.Prefix, "/")
+	if opts.Prefix != "" {
+		opts.Prefix += "/"
+	}
+	opts.PublicURL = strings.TrimSuffix(opts.PublicURL, "/")
+
+	return &S3{client: client, opts: opts}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.opts.Bucket, s.opts.Prefix+key, r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return err
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	obj, err := s.client.GetObject(ctx, s.opts.Bucket, s.opts.Prefix+key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	// GetObject ленивый: отсутствие объекта выясняется только при первом обращении
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, mapS3Error(err)
+	}
+	return obj, nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.opts.Bucket, s.opts.Prefix+key, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	return s.objectInfo(info), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.opts.Bucket, s.opts.Prefix+key, minio.RemoveObjectOptions{})
+}
+
+func (s *S3) List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := s.client.ListObjects(ctx, s.opts.Bucket, minio.ListObjectsOptions{
+		Prefix:    s.opts.Prefix + prefix,
+		Recursive: true,
+	})
+	for obj := range objects {
+		if obj.Err != nil {
+			return obj.Err
+		}
+		if err := fn(*s.objectInfo(obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3) URL(ctx context.Context, key string) (string, error) {
+	if s.opts.PublicURL != "" {
+		return s.opts.PublicURL + "/" + s.opts.Prefix + key, nil
+	}
+
+	u, err := s.client.PresignedGetObject(ctx, s.opts.Bucket, s.opts.Prefix+key, s.opts.PresignTTL, nil)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func (s *S3) objectInfo(info minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:         strings.TrimPrefix(info.Key, s.opts.Prefix),
+		Size:        info.Size,
+		ModTime:     info.LastModified,
+		ContentType: info.ContentType,
+		ETag:        info.ETag,
+	}
+}
+
+func mapS3Error(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return err
+}
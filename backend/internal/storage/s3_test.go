@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Интеграционный тест S3 запускается только с локальным S3-совместимым
+// хранилищем, например MinIO из docker-compose.dev.yml (профиль s3):
+//
+//	S3_TEST_ENDPOINT=localhost:9000 go test ./internal/storage -run S3
+//
+// Бакет (S3_TEST_BUCKET, по умолчанию images) должен существовать. Объекты
+// пишутся под уникальным префиксом и удаляются в конце теста.
+func newTestS3(t *testing.T) *S3 {
+	t.Helper()
+
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+
+	store, err := NewS3(S3Options{
+		Endpoint:   endpoint,
+		Region:     testEnv("S3_TEST_REGION", "us-east-1"),
+		Bucket:     testEnv("S3_TEST_BUCKET", "images"),
+		AccessKey:  testEnv("S3_TEST_ACCESS_KEY", "minioadmin"),
+		SecretKey:  testEnv("S3_TEST_SECRET_KEY", "minioadmin"),
+		PathStyle:  true,
+		Prefix:     "storage-test-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		PresignTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("NewS3: %v", err)
+	}
+
+	t.Cleanup(func() {
+		var keys []string
+		_ = store.List(context.Background(), "", func(info ObjectInfo) error {
+			keys = append(keys, info.Key)
+			return nil
+		})
+		for _, key := range keys {
+			_ = store.Delete(context.Background(), key)
+		}
+	})
+	return store
+}
+
+func testEnv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestS3Objects(t *testing.T) {
+	store := newTestS3(t)
+	ctx := context.Background()
+	key := "ab/cd/object.png"
+	data := []byte("not really a png, but bytes are bytes")
+
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	info, err := store.Stat(ctx, key)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Key != key || info.Size != int64(len(data)) || info.ContentType != "image/png" {
+		t.Errorf("Stat = %+v, want key %s, size %d, image/png", info, key, len(data))
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read object: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get returned %q, want %q", got, data)
+	}
+	// Отдача диапазонов читает объект с произвольной позиции
+	if _, err := r.Seek(4, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	got, err = io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read after seek: %v", err)
+	}
+	if !bytes.Equal(got, data[4:]) {
+		t.Errorf("read after seek returned %q, want %q", got, data[4:])
+	}
+	r.Close()
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Stat(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat after Delete: err = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: err = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of missing object: %v", err)
+	}
+}
+
+func TestS3List(t *testing.T) {
+	store := newTestS3(t)
+	ctx := context.Background()
+	keys := []string{"aa/01/one.jpg", "aa/02/two.jpg", "bb/01/three.jpg"}
+	for _, key := range keys {
+		if err := store.Put(ctx, key, bytes.NewReader([]byte(key)), int64(len(key)), "image/jpeg"); err != nil {
+			t.Fatalf("Put %s: %v", key, err)
+		}
+	}
+
+	list := func(prefix string) []string {
+		t.Helper()
+		var listed []string
+		err := store.List(ctx, prefix, func(info ObjectInfo) error {
+			listed = append(listed, info.Key)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("List(%q): %v", prefix, err)
+		}
+		slices.Sort(listed)
+		return listed
+	}
+
+	if got := list(""); !slices.Equal(got, keys) {
+		t.Errorf("List(\"\") = %v, want %v", got, keys)
+	}
+	if got, want := list("aa/"), keys[:2]; !slices.Equal(got, want) {
+		t.Errorf("List(\"aa/\") = %v, want %v", got, want)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := store.List(ctx, "", func(ObjectInfo) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("List with failing fn: err = %v after %d calls, want stop after 1", err, calls)
+	}
+}
+
+func TestS3PresignedURL(t *testing.T) {
+	store := newTestS3(t)
+	ctx := context.Background()
+	key := "cc/dd/presigned.gif"
+	data := []byte("GIF89a presigned")
+
+	if err := store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), "image/gif"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	u, err := store.URL(ctx, key)
+	if err != nil {
+		t.Fatalf("URL: %v", err)
+	}
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatalf("GET presigned URL: %v", err)
+	}
+	defer resp.Body.Close()
+	got, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(got, data) {
+		t.Errorf("GET presigned URL: status %d, body %q; want 200, %q", resp.StatusCode, got, data)
+	}
+
+	store.opts.PublicURL = "https://cdn.example.com"
+	u, err = store.URL(ctx, key)
+	if err != nil {
+		t.Fatalf("URL with PublicURL: %v", err)
+	}
+	if want := "https://cdn.example.com/" + store.opts.Prefix + key; u != want {
+		t.Errorf("URL with PublicURL = %s, want %s", u, want)
+	}
+}
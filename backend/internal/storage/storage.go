@@ -0,0 +1,63 @@
+// Package storage хранит файлы изображений под ключами, не зависящими от
+// того, где они лежат: на локальном диске или в S3-совместимом хранилище.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// ObjectInfo — сведения об объекте
+type ObjectInfo struct {
+	Key         string
+	Size        int64
+	ModTime     time.Time
+	ContentType string
+	ETag        string
+}
+
+// Storage — хранилище объектов. Ключ — путь через "/" без ведущего слеша,
+// например "ab/cd/<sha256>.jpg".
+type Storage interface {
+	// Put записывает объект целиком. Читатели никогда не видят недописанный объект.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Get открывает объект на чтение; ErrNotFound, если его нет. Чтение
+	// с произвольной позиции нужно для отдачи диапазонов (Range).
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, error)
+
+	// Stat возвращает сведения об объекте; ErrNotFound, если его нет
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+
+	// Delete удаляет объект. Удаление отсутствующего объекта не ошибка.
+	Delete(ctx context.Context, key string) error
+
+	// List вызывает fn для каждого объекта с ключом, начинающимся с prefix.
+	// Ошибка fn прекращает обход и возвращается из List.
+	List(ctx context.Context, prefix string, fn func(ObjectInfo) error) error
+
+	// URL возвращает адрес, по которому клиент может скачать объект:
+	// публичный или подписанный на ограниченное время
+	URL(ctx context.Context, key string) (string, error)
+}
+
+// CleanKey приводит ключ к каноническому виду и отклоняет ключи,
+// выходящие за пределы хранилища ("..") или указывающие на служебные
+// объекты (сегменты, начинающиеся с точки)
+func CleanKey(key string) (string, bool) {
+	key = strings.Trim(key, "/")
+	if key == "" {
+		return "", false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || strings.HasPrefix(segment, ".") {
+			return "", false
+		}
+	}
+	return key, true
+}
@@ -0,0 +1,23 @@
+-- Пользователи
+CREATE TABLE IF NOT EXISTS users (
+    id            TEXT PRIMARY KEY,
+    username      TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    role          TEXT NOT NULL DEFAULT 'user',
+    created_at    DATETIME NOT NULL
+);
+
+-- Загруженные изображения
+CREATE TABLE IF NOT EXISTS images (
+    id            TEXT PRIMARY KEY,
+    user_id       TEXT NOT NULL REFERENCES users(id),
+    original_name TEXT NOT NULL,
+    file_name     TEXT NOT NULL,
+    file_path     TEXT NOT NULL,
+    mime_type     TEXT NOT NULL,
+    size          INTEGER NOT NULL,
+    created_at    DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_images_user_id ON images(user_id);
+CREATE INDEX IF NOT EXISTS idx_images_created_at ON images(created_at);
@@ -0,0 +1,23 @@
+-- Инвайт-коды для регистрации. Роль нового пользователя задаётся инвайтом,
+-- а не телом запроса на регистрацию.
+CREATE TABLE IF NOT EXISTS invites (
+    id         TEXT PRIMARY KEY,
+    code       TEXT NOT NULL UNIQUE,
+    role       TEXT NOT NULL DEFAULT 'user',
+    max_uses   INTEGER NOT NULL DEFAULT 1,
+    uses       INTEGER NOT NULL DEFAULT 0,
+    expires_at DATETIME,
+    created_by TEXT REFERENCES users(id) ON DELETE SET NULL,
+    created_at DATETIME NOT NULL,
+    revoked_at DATETIME
+);
+
+-- Каждое использование инвайта: кто кого пригласил
+CREATE TABLE IF NOT EXISTS invite_redemptions (
+    id          TEXT PRIMARY KEY,
+    invite_id   TEXT NOT NULL REFERENCES invites(id) ON DELETE CASCADE,
+    user_id     TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    redeemed_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_invite_redemptions_invite_id ON invite_redemptions(invite_id);
@@ -0,0 +1,7 @@
+-- Очередь удаления файлов. Путь попадает сюда в одной транзакции с удалением
+-- строки из images, а сам файл удаляется после коммита. Если удаление файла
+-- не удалось, запись остаётся и обрабатывается повторно при следующем запуске.
+CREATE TABLE IF NOT EXISTS file_deletions (
+    path       TEXT PRIMARY KEY,
+    created_at DATETIME NOT NULL
+);
@@ -0,0 +1,14 @@
+-- Уменьшенные копии изображений (миниатюры и адаптивные размеры).
+-- Файлы лежат рядом с оригиналом, удаляются вместе с ним.
+CREATE TABLE IF NOT EXISTS image_variants (
+    image_id   TEXT NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+    name       TEXT NOT NULL,
+    file_name  TEXT NOT NULL,
+    file_path  TEXT NOT NULL,
+    mime_type  TEXT NOT NULL,
+    width      INTEGER NOT NULL,
+    height     INTEGER NOT NULL,
+    size       INTEGER NOT NULL,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (image_id, name)
+);
@@ -0,0 +1,17 @@
+-- Размеры изображения после обработки (с учетом автоповорота)
+ALTER TABLE images ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE images ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
+
+-- Метаданные EXIF, извлеченные при загрузке. GPS сохраняется только если
+-- он не вырезается из файла настройкой EXIF_STRIP.
+CREATE TABLE IF NOT EXISTS image_metadata (
+    image_id      TEXT PRIMARY KEY REFERENCES images(id) ON DELETE CASCADE,
+    orientation   INTEGER NOT NULL DEFAULT 1,
+    taken_at      DATETIME,
+    camera_make   TEXT NOT NULL DEFAULT '',
+    camera_model  TEXT NOT NULL DEFAULT '',
+    lens_model    TEXT NOT NULL DEFAULT '',
+    gps_latitude  REAL,
+    gps_longitude REAL,
+    stripped      TEXT NOT NULL DEFAULT 'none'
+);
@@ -0,0 +1,16 @@
+-- SHA-256 сохраненного содержимого. Файлы хранятся по хешу и разделяются
+-- между строками images; у изображений, загруженных раньше, хеш пустой
+-- и файл принадлежит только им.
+ALTER TABLE images ADD COLUMN sha256 TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_images_user_sha256 ON images(user_id, sha256);
+
+-- Файлы с содержимым и число строк images, которые на них ссылаются.
+-- Файл удаляется с диска, когда счетчик доходит до нуля.
+CREATE TABLE IF NOT EXISTS blobs (
+    sha256     TEXT PRIMARY KEY,
+    file_path  TEXT NOT NULL,
+    size       INTEGER NOT NULL,
+    ref_count  INTEGER NOT NULL,
+    created_at DATETIME NOT NULL
+);
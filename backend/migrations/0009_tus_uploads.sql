@@ -0,0 +1,15 @@
+-- Незавершенные загрузки по протоколу tus. Данные лежат в UPLOAD_DIR/.tus/<id>,
+-- после сборки файл проходит обычное сохранение и становится изображением.
+CREATE TABLE IF NOT EXISTS tus_uploads (
+    id            TEXT PRIMARY KEY,
+    user_id       TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    length        INTEGER NOT NULL,
+    upload_offset INTEGER NOT NULL DEFAULT 0,
+    filename      TEXT NOT NULL DEFAULT '',
+    filetype      TEXT NOT NULL DEFAULT '',
+    image_id      TEXT REFERENCES images(id) ON DELETE SET NULL,
+    created_at    DATETIME NOT NULL,
+    expires_at    DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tus_uploads_expires_at ON tus_uploads(expires_at);
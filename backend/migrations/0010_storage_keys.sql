@@ -0,0 +1,17 @@
+-- Файлы адресуются ключом в хранилище (локальная папка или S3-бакет),
+-- а не путем на диске. Ключ — путь относительно корня хранилища через "/".
+ALTER TABLE images RENAME COLUMN file_path TO storage_key;
+ALTER TABLE image_variants RENAME COLUMN file_path TO storage_key;
+ALTER TABLE blobs RENAME COLUMN file_path TO storage_key;
+
+-- Очередь удаления теперь хранит ключи объектов
+ALTER TABLE file_deletions RENAME TO storage_deletions;
+ALTER TABLE storage_deletions RENAME COLUMN path TO storage_key;
+
+-- В строках, созданных до этой миграции, вместо ключей лежат пути на диске.
+-- Они переводятся в ключи относительно UPLOAD_DIR при старте сервера,
+-- после чего запись удаляется.
+CREATE TABLE IF NOT EXISTS storage_key_backfill (
+    pending INTEGER NOT NULL
+);
+INSERT INTO storage_key_backfill (pending) VALUES (1);
@@ -0,0 +1,10 @@
+-- Прогресс переноса файлов в другое хранилище (команда migrate-storage).
+-- target — описание хранилища-цели; скопированные и проверенные объекты
+-- записываются пачками, поэтому прерванный перенос продолжается с места остановки.
+CREATE TABLE IF NOT EXISTS storage_migration_progress (
+    target      TEXT NOT NULL,
+    storage_key TEXT NOT NULL,
+    size        INTEGER NOT NULL,
+    copied_at   DATETIME NOT NULL,
+    PRIMARY KEY (target, storage_key)
+);
@@ -0,0 +1,11 @@
+-- Видимость изображения: public — доступно всем, unlisted — только по ссылке
+-- с access_token, private — только владельцу и администраторам.
+-- Уже загруженные изображения остаются публичными.
+ALTER TABLE images ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE images ADD COLUMN access_token TEXT NOT NULL DEFAULT '';
+
+-- Случайный токен для ссылок на unlisted изображения
+UPDATE images SET access_token = lower(hex(randomblob(16)));
+
+-- Видимость, выбранная при создании tus-загрузки, применяется после сборки
+ALTER TABLE tus_uploads ADD COLUMN visibility TEXT NOT NULL DEFAULT '';
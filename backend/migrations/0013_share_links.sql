@@ -0,0 +1,36 @@
+-- Ссылки /s/<slug> на одно или несколько изображений владельца
+CREATE TABLE IF NOT EXISTS share_links (
+    id             TEXT PRIMARY KEY,
+    slug           TEXT NOT NULL UNIQUE,
+    user_id        TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    password_hash  TEXT NOT NULL DEFAULT '',   -- Пусто — без пароля
+    max_views      INTEGER NOT NULL DEFAULT 0, -- 0 — без ограничения
+    view_count     INTEGER NOT NULL DEFAULT 0,
+    last_viewed_at DATETIME,
+    expires_at     DATETIME,
+    created_at     DATETIME NOT NULL,
+    revoked_at     DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_links_user_id ON share_links(user_id);
+
+-- Изображения ссылки в порядке показа. Удаленное изображение пропадает из ссылки.
+CREATE TABLE IF NOT EXISTS share_link_images (
+    share_id TEXT NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+    image_id TEXT NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (share_id, image_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_link_images_image_id ON share_link_images(image_id);
+
+-- Засчитанные просмотры. Токен просмотра открывает файлы ссылки на время,
+-- чтобы при ограничении числа просмотров файлы не были доступны бессрочно.
+CREATE TABLE IF NOT EXISTS share_link_views (
+    token      TEXT PRIMARY KEY,
+    share_id   TEXT NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+    viewed_at  DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_link_views_share_id ON share_link_views(share_id);
@@ -0,0 +1,29 @@
+-- Альбомы пользователя. Изображение может входить в несколько альбомов.
+-- Видимость альбома определяет, кому виден его состав; доступ к файлам
+-- по-прежнему определяется видимостью самих изображений.
+CREATE TABLE IF NOT EXISTS albums (
+    id             TEXT PRIMARY KEY,
+    user_id        TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name           TEXT NOT NULL,
+    description    TEXT NOT NULL DEFAULT '',
+    visibility     TEXT NOT NULL DEFAULT 'private',
+    access_token   TEXT NOT NULL DEFAULT '',
+    cover_image_id TEXT REFERENCES images(id) ON DELETE SET NULL, -- NULL — первое изображение
+    created_at     DATETIME NOT NULL,
+    updated_at     DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_albums_user_id ON albums(user_id);
+
+CREATE TABLE IF NOT EXISTS album_images (
+    album_id TEXT NOT NULL REFERENCES albums(id) ON DELETE CASCADE,
+    image_id TEXT NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    added_at DATETIME NOT NULL,
+    PRIMARY KEY (album_id, image_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_album_images_image_id ON album_images(image_id);
+
+-- Альбом, в который попадет изображение после сборки tus-загрузки
+ALTER TABLE tus_uploads ADD COLUMN album_id TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,12 @@
+-- Теги изображений. Хранятся нормализованными (нижний регистр, одиночные
+-- пробелы), поэтому "Cat" и "cat " — один и тот же тег.
+CREATE TABLE IF NOT EXISTS image_tags (
+    image_id TEXT NOT NULL REFERENCES images(id) ON DELETE CASCADE,
+    tag      TEXT NOT NULL,
+    PRIMARY KEY (image_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_image_tags_tag ON image_tags(tag);
+
+-- Теги, заданные при создании tus-загрузки, через запятую
+ALTER TABLE tus_uploads ADD COLUMN tags TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,53 @@
+-- Подпись изображения, задается владельцем
+ALTER TABLE images ADD COLUMN caption TEXT NOT NULL DEFAULT '';
+
+-- Полнотекстовый поиск по имени файла, подписи и тегам. Строка индекса
+-- связана с изображением через image_search_ids: у images нет целочисленного
+-- ключа, а неявный rowid может измениться после VACUUM.
+CREATE TABLE IF NOT EXISTS image_search_ids (
+    id       INTEGER PRIMARY KEY,
+    image_id TEXT NOT NULL UNIQUE
+);
+
+CREATE VIRTUAL TABLE IF NOT EXISTS images_fts USING fts5(
+    original_name, caption, tags,
+    tokenize = 'unicode61 remove_diacritics 2',
+    prefix = '2 3'
+);
+
+-- Индекс обновляется триггерами, поэтому остается согласованным при любом
+-- изменении images и image_tags, в том числе из транзакций репозитория
+CREATE TRIGGER IF NOT EXISTS images_search_insert AFTER INSERT ON images BEGIN
+    INSERT INTO image_search_ids (image_id) VALUES (NEW.id);
+    INSERT INTO images_fts (rowid, original_name, caption, tags)
+    VALUES ((SELECT id FROM image_search_ids WHERE image_id = NEW.id), NEW.original_name, NEW.caption, '');
+END;
+
+CREATE TRIGGER IF NOT EXISTS images_search_update AFTER UPDATE OF original_name, caption ON images BEGIN
+    UPDATE images_fts SET original_name = NEW.original_name, caption = NEW.caption
+    WHERE rowid = (SELECT id FROM image_search_ids WHERE image_id = NEW.id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS images_search_delete AFTER DELETE ON images BEGIN
+    DELETE FROM images_fts WHERE rowid = (SELECT id FROM image_search_ids WHERE image_id = OLD.id);
+    DELETE FROM image_search_ids WHERE image_id = OLD.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS image_tags_search_insert AFTER INSERT ON image_tags BEGIN
+    UPDATE images_fts SET tags = (SELECT group_concat(tag, ' ') FROM image_tags WHERE image_id = NEW.image_id)
+    WHERE rowid = (SELECT id FROM image_search_ids WHERE image_id = NEW.image_id);
+END;
+
+CREATE TRIGGER IF NOT EXISTS image_tags_search_delete AFTER DELETE ON image_tags BEGIN
+    UPDATE images_fts SET tags = COALESCE((SELECT group_concat(tag, ' ') FROM image_tags WHERE image_id = OLD.image_id), '')
+    WHERE rowid = (SELECT id FROM image_search_ids WHERE image_id = OLD.image_id);
+END;
+
+-- Индекс для уже загруженных изображений
+INSERT INTO image_search_ids (image_id) SELECT id FROM images;
+
+INSERT INTO images_fts (rowid, original_name, caption, tags)
+SELECT s.id, i.original_name, i.caption,
+    COALESCE((SELECT group_concat(t.tag, ' ') FROM image_tags t WHERE t.image_id = i.id), '')
+FROM images i
+JOIN image_search_ids s ON s.image_id = i.id;
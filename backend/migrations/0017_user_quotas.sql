@@ -0,0 +1,34 @@
+-- Квоты пользователей: использование хранилища и лимиты, заданные
+-- администратором. NULL в max_bytes/max_images — лимит по умолчанию
+-- для роли, 0 — без ограничения.
+CREATE TABLE IF NOT EXISTS user_quotas (
+    user_id     TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    bytes_used  INTEGER NOT NULL DEFAULT 0,
+    images_used INTEGER NOT NULL DEFAULT 0,
+    max_bytes   INTEGER,
+    max_images  INTEGER
+);
+
+-- Использование ведется триггерами при каждом изменении images, поэтому
+-- не требует пересчета SUM(size) и не расходится с таблицей
+CREATE TRIGGER IF NOT EXISTS images_quota_insert AFTER INSERT ON images BEGIN
+    INSERT INTO user_quotas (user_id, bytes_used, images_used) VALUES (NEW.user_id, NEW.size, 1)
+    ON CONFLICT (user_id) DO UPDATE SET bytes_used = bytes_used + NEW.size, images_used = images_used + 1;
+END;
+
+CREATE TRIGGER IF NOT EXISTS images_quota_delete AFTER DELETE ON images BEGIN
+    UPDATE user_quotas SET bytes_used = bytes_used - OLD.size, images_used = images_used - 1
+    WHERE user_id = OLD.user_id;
+END;
+
+-- Передача изображения другому пользователю или изменение размера
+CREATE TRIGGER IF NOT EXISTS images_quota_update AFTER UPDATE OF user_id, size ON images BEGIN
+    UPDATE user_quotas SET bytes_used = bytes_used - OLD.size, images_used = images_used - 1
+    WHERE user_id = OLD.user_id;
+    INSERT INTO user_quotas (user_id, bytes_used, images_used) VALUES (NEW.user_id, NEW.size, 1)
+    ON CONFLICT (user_id) DO UPDATE SET bytes_used = bytes_used + NEW.size, images_used = images_used + 1;
+END;
+
+-- Использование уже загруженных изображений
+INSERT INTO user_quotas (user_id, bytes_used, images_used)
+SELECT user_id, SUM(size), COUNT(*) FROM images GROUP BY user_id;
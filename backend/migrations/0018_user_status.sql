@@ -0,0 +1,6 @@
+-- Отключение учетной записи администратором: отключенный пользователь
+-- не может войти, его сессии и API токены не принимаются
+ALTER TABLE users ADD COLUMN disabled_at DATETIME;
+
+-- Пароль сброшен администратором: войти можно только после смены пароля
+ALTER TABLE users ADD COLUMN password_reset_required INTEGER NOT NULL DEFAULT 0;
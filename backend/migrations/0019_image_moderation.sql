@@ -0,0 +1,7 @@
+-- Модерация изображений администратором. Скрытое изображение доступно
+-- только владельцу и администраторам, независимо от видимости, ссылок
+-- и альбомов. Отметка flagged_at — изображение ждет проверки.
+ALTER TABLE images ADD COLUMN hidden_at DATETIME;
+ALTER TABLE images ADD COLUMN flagged_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_images_flagged_at ON images(flagged_at) WHERE flagged_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Подпись, заданная при создании tus-загрузки
+ALTER TABLE tus_uploads ADD COLUMN caption TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,21 @@
+-- История использования инвайтов переживает удаление пользователя:
+-- user_id обнуляется, а имя, под которым он зарегистрировался, хранится
+-- в самой записи. SQLite не меняет внешние ключи через ALTER TABLE,
+-- поэтому таблица пересоздается.
+CREATE TABLE invite_redemptions_new (
+    id          TEXT PRIMARY KEY,
+    invite_id   TEXT NOT NULL REFERENCES invites(id) ON DELETE CASCADE,
+    user_id     TEXT REFERENCES users(id) ON DELETE SET NULL,
+    username    TEXT NOT NULL,
+    redeemed_at DATETIME NOT NULL
+);
+
+INSERT INTO invite_redemptions_new (id, invite_id, user_id, username, redeemed_at)
+SELECT r.id, r.invite_id, r.user_id, u.username, r.redeemed_at
+FROM invite_redemptions r
+JOIN users u ON u.id = r.user_id;
+
+DROP TABLE invite_redemptions;
+ALTER TABLE invite_redemptions_new RENAME TO invite_redemptions;
+
+CREATE INDEX IF NOT EXISTS idx_invite_redemptions_invite_id ON invite_redemptions(invite_id);
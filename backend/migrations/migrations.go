@@ -0,0 +1,11 @@
+// Package migrations содержит SQL миграции схемы базы данных.
+//
+// Файлы именуются как NNNN_description.sql, где NNNN — номер версии.
+// Миграции применяются только вперёд: уже применённый файл нельзя
+// менять, любое изменение схемы оформляется новым файлом.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -142,15 +142,35 @@ export function uploadImage(
 }
 
 // API для админа
+export interface Page<T> {
+  items: T[];
+  next_cursor?: string;
+  total: number;
+}
+
+// Загружает все страницы списка, переходя по next_cursor
+async function getAllPages<T>(endpoint: string): Promise<T[]> {
+  const items: T[] = [];
+  let cursor: string | undefined;
+  do {
+    const params = new URLSearchParams({ limit: '200' });
+    if (cursor) {
+      params.set('cursor', cursor);
+    }
+    const page = await apiRequest<Page<T>>(`${endpoint}?${params}`, {
+      method: 'GET',
+    });
+    items.push(...page.items);
+    cursor = page.next_cursor;
+  } while (cursor);
+  return items;
+}
+
 export async function getAdminUsers(): Promise<any[]> {
-  return apiRequest<any[]>('/api/admin/users', {
-    method: 'GET',
-  });
+  return getAllPages<any>('/api/admin/users');
 }
 
 export async function getUserImages(userId: string): Promise<any[]> {
-  return apiRequest<any[]>(`/api/admin/users/${userId}/images`, {
-    method: 'GET',
-  });
+  return getAllPages<any>(`/api/admin/users/${userId}/images`);
 }
 